@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -14,7 +15,7 @@ type ChatService struct {
 }
 
 func (c *ChatService) Chat(ctx context.Context, in, out chan string) error {
-	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:1865/ws/user", nil)
+	conn, err := c.client.dialWS(ctx, "/ws/user")
 	if err != nil {
 		return err
 	}
@@ -61,3 +62,86 @@ func (c *ChatService) Chat(ctx context.Context, in, out chan string) error {
 		}
 	}
 }
+
+// ChatReply is the reply of the Cat to a message
+type ChatReply struct {
+	Content string
+	// TimeToFirstToken is the time of the first streamed token, or of the reply if the LLM is not streaming
+	TimeToFirstToken time.Duration
+	Latency          time.Duration
+	// Tokens is the number of streamed tokens, zero if the LLM is not streaming
+	Tokens int
+}
+
+// ChatError is an error frame sent by the Cat, for example when the LLM fails
+type ChatError struct {
+	Name        string
+	Description string
+}
+
+func (e *ChatError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Description)
+}
+
+// Send sends a message on a new websocket of the user, and waits for the reply.
+// The websocket is not shared with the notifications of the uploads, so the user should be a different one.
+func (c *ChatService) Send(ctx context.Context, userID, text string) (*ChatReply, error) {
+	if userID == "" {
+		userID = DefaultUserID
+	}
+
+	conn, err := c.client.dialWS(ctx, "/ws/"+userID)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// unblocks the read when the context is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	msg, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		return nil, err
+	}
+
+	reply := &ChatReply{}
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		var frame wsMessage
+		if err := json.Unmarshal(b, &frame); err != nil {
+			return nil, err
+		}
+
+		switch frame.Type {
+		case "chat_token":
+			if reply.Tokens == 0 {
+				reply.TimeToFirstToken = time.Since(start)
+			}
+			reply.Tokens++
+
+		case "chat":
+			reply.Latency = time.Since(start)
+			if reply.Tokens == 0 {
+				reply.TimeToFirstToken = reply.Latency
+			}
+			reply.Content = frame.Content
+			return reply, nil
+
+		case "error":
+			return nil, &ChatError{Name: frame.Name, Description: frame.Description}
+		}
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
 )
 
 type Client struct {
@@ -14,10 +19,14 @@ type Client struct {
 	BaseURL    string
 	APIKey     string
 
-	Settings *SettingsService
-	LLM      *LLMService
-	Server   *ServerService
-	Chat     *ChatService
+	Settings   *SettingsService
+	LLM        *LLMService
+	Embedder   *EmbedderService
+	Server     *ServerService
+	Chat       *ChatService
+	Plugins    *PluginsService
+	Memory     *MemoryService
+	RabbitHole *RabbitHoleService
 }
 
 type clientOpt func(c *Client) error
@@ -36,12 +45,42 @@ func NewClient(opts ...clientOpt) (*Client, error) {
 
 	c.Settings = &SettingsService{c}
 	c.LLM = &LLMService{c}
+	c.Embedder = &EmbedderService{c}
 	c.Server = &ServerService{c}
 	c.Chat = &ChatService{c}
+	c.Plugins = &PluginsService{c}
+	c.Memory = &MemoryService{c}
+	c.RabbitHole = &RabbitHoleService{client: c}
 
 	return c, nil
 }
 
+// wsURL returns the websocket URL of the path, on the same host of the BaseURL
+func (c *Client) wsURL(path string) string {
+	base := c.BaseURL
+	if after, found := strings.CutPrefix(base, "https://"); found {
+		base = "wss://" + after
+	} else if after, found := strings.CutPrefix(base, "http://"); found {
+		base = "ws://" + after
+	}
+	return base + path
+}
+
+// dialWS connects to the websocket of the path, authenticated with the API key.
+// The key is sent as header and as the token query param, that is the one checked by the Cat on websockets.
+func (c *Client) dialWS(ctx context.Context, path string) (*websocket.Conn, error) {
+	wsURL := c.wsURL(path)
+	header := http.Header{}
+
+	if c.APIKey != "" {
+		header.Set("Access_token", c.APIKey)
+		wsURL += "?" + url.Values{"token": {c.APIKey}}.Encode()
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	return conn, err
+}
+
 func WithHttpClient(httpClient *http.Client) clientOpt {
 	return func(c *Client) error {
 		c.HttpClient = httpClient
@@ -99,20 +138,53 @@ func del[R any](ctx context.Context, c *Client, path string, response R) (*CatRe
 }
 
 func do[R any](ctx context.Context, c *Client, method, path string, payload any, response R) (*CatResponse[R], error) {
+	return doWithHeader(ctx, c, method, path, payload, http.Header{}, response)
+}
+
+func doWithHeader[R any](ctx context.Context, c *Client, method, path string, payload any, header http.Header, response R) (*CatResponse[R], error) {
 	requestBody := new(bytes.Buffer)
+
 	if payload != nil {
 		err := json.NewEncoder(requestBody).Encode(payload)
 		if err != nil {
 			return nil, err
 		}
+		header.Set("Content-Type", "application/json")
 	}
 
+	return send(ctx, c, method, path, requestBody, header, response)
+}
+
+// postMultipart streams a multipart/form-data body, written by the provided func, without buffering it
+func postMultipart[R any](ctx context.Context, c *Client, path string, header http.Header, write func(w *multipart.Writer) error, response R) (*CatResponse[R], error) {
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := write(mw)
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	header.Set("Content-Type", mw.FormDataContentType())
+
+	return send(ctx, c, http.MethodPost, path, pr, header, response)
+}
+
+func send[R any](ctx context.Context, c *Client, method, path string, body io.Reader, header http.Header, response R) (*CatResponse[R], error) {
 	url := c.BaseURL + path
-	req, err := http.NewRequestWithContext(ctx, method, url, requestBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
 
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
 	if c.APIKey != "" {
 		req.Header.Set("Access_token", c.APIKey)
 	}
@@ -144,6 +216,7 @@ func do[R any](ctx context.Context, c *Client, method, path string, payload any,
 		return nil, err
 	}
 	catResp.Value = response
+	catResp.Raw = responseBody
 
 	return catResp, nil
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// catSeparators are the separators used by the Cat text splitter, from the preferred one.
+// The escaped ones match texts with literal "\n" sequences.
+var catSeparators = []string{`\n\n`, "\n\n", `.\n`, ".\n", `\n`, "\n", " ", ""}
+
+// tokenPattern approximates the pre-tokenization of the cl100k_base encoding used by the Cat to measure the chunks.
+// Long words are split in more tokens by the real encoder, so the counts are a lower bound.
+var tokenPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+func countTokens(s string) int {
+	return len(tokenPattern.FindAllStringIndex(s, -1))
+}
+
+func countChars(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// textSplitter is a port of the recursive character text splitter of LangChain, configured as the Cat does:
+// the text is split by the first separator found in it, and the pieces still too long are split recursively
+// with the next separators. The pieces are then merged into chunks of at most chunkSize, overlapping by chunkOverlap.
+type textSplitter struct {
+	chunkSize    int
+	chunkOverlap int
+	separators   []string
+	length       func(string) int
+}
+
+func (t *textSplitter) split(text string) []string {
+	return t.splitText(text, t.separators)
+}
+
+func (t *textSplitter) splitText(text string, separators []string) []string {
+	finalChunks := []string{}
+
+	separator := separators[len(separators)-1]
+	newSeparators := []string{}
+	for i, s := range separators {
+		if s == "" {
+			separator = s
+			break
+		}
+		if strings.Contains(text, s) {
+			separator = s
+			newSeparators = separators[i+1:]
+			break
+		}
+	}
+
+	goodSplits := []string{}
+	for _, s := range splitKeepingSeparator(text, separator) {
+		if t.length(s) < t.chunkSize {
+			goodSplits = append(goodSplits, s)
+			continue
+		}
+
+		if len(goodSplits) > 0 {
+			finalChunks = append(finalChunks, t.mergeSplits(goodSplits)...)
+			goodSplits = []string{}
+		}
+
+		if len(newSeparators) == 0 {
+			finalChunks = append(finalChunks, s)
+		} else {
+			finalChunks = append(finalChunks, t.splitText(s, newSeparators)...)
+		}
+	}
+
+	if len(goodSplits) > 0 {
+		finalChunks = append(finalChunks, t.mergeSplits(goodSplits)...)
+	}
+
+	return finalChunks
+}
+
+// mergeSplits combines the splits into chunks, keeping the last splits of a chunk at the start of the next one as overlap.
+// The separators are kept in the splits, so they are joined without adding anything.
+func (t *textSplitter) mergeSplits(splits []string) []string {
+	docs := []string{}
+	currentDoc := []string{}
+	total := 0
+
+	for _, d := range splits {
+		l := t.length(d)
+
+		if total+l > t.chunkSize && len(currentDoc) > 0 {
+			if doc := joinDocs(currentDoc); doc != "" {
+				docs = append(docs, doc)
+			}
+
+			for total > t.chunkOverlap || (total+l > t.chunkSize && total > 0) {
+				total -= t.length(currentDoc[0])
+				currentDoc = currentDoc[1:]
+			}
+		}
+
+		currentDoc = append(currentDoc, d)
+		total += l
+	}
+
+	if doc := joinDocs(currentDoc); doc != "" {
+		docs = append(docs, doc)
+	}
+
+	return docs
+}
+
+func joinDocs(docs []string) string {
+	return strings.TrimSpace(strings.Join(docs, ""))
+}
+
+// splitKeepingSeparator splits the text by the separator, keeping it at the start of the following piece.
+// The empty separator splits the text in characters.
+func splitKeepingSeparator(text, separator string) []string {
+	splits := []string{}
+
+	if separator == "" {
+		for _, r := range text {
+			splits = append(splits, string(r))
+		}
+		return splits
+	}
+
+	pieces := strings.Split(text, separator)
+	for i, piece := range pieces {
+		if i > 0 {
+			piece = separator + piece
+		}
+		if piece != "" {
+			splits = append(splits, piece)
+		}
+	}
+
+	return splits
+}
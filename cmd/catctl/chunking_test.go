@@ -0,0 +1,86 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestTextSplitter checks the chunks against the ones of the LangChain RecursiveCharacterTextSplitter,
+// with keep_separator and strip_whitespace enabled, for the same inputs, sizes and separators
+func TestTextSplitter(t *testing.T) {
+	tests := []struct {
+		name         string
+		chunkSize    int
+		chunkOverlap int
+		separators   []string
+		text         string
+		expected     []string
+	}{
+		{
+			// the separators are kept at the start of the following piece, and trimmed from the chunks
+			name:       "keep separator",
+			chunkSize:  10,
+			separators: []string{"\n", " ", ""},
+			text:       "one two\nthree four five\nsix",
+			expected:   []string{"one two", "three", "four five", "six"},
+		},
+		{
+			// the first splits of a chunk are dropped until the rest fits in the overlap
+			name:         "overlap",
+			chunkSize:    12,
+			chunkOverlap: 6,
+			separators:   []string{" ", ""},
+			text:         "the quick brown fox jumps over the lazy dog",
+			expected:     []string{"the quick", "quick brown", "brown fox", "fox jumps", "jumps over", "over the", "the lazy", "lazy dog"},
+		},
+		{
+			// the words longer than the chunk size are split in characters
+			name:         "character fallback",
+			chunkSize:    5,
+			chunkOverlap: 2,
+			separators:   []string{" ", ""},
+			text:         "ab abcdefghijkl cd",
+			expected:     []string{"ab", "abcd", "cdefg", "fghij", "ijkl", "cd"},
+		},
+		{
+			name:         "cat separators",
+			chunkSize:    20,
+			chunkOverlap: 5,
+			separators:   catSeparators,
+			text:         "First paragraph here.\n\nSecond one is a bit longer.\nLast line",
+			expected:     []string{"First paragraph", "here.", "Second one is a bit", "bit longer", ".\nLast line"},
+		},
+		{
+			name:       "escaped newlines",
+			chunkSize:  16,
+			separators: catSeparators,
+			text:       `line one\nline two\n\nline three`,
+			expected:   []string{"line one", `\nline two`, `\n\nline three`},
+		},
+		{
+			// the length is measured in characters, not in bytes
+			name:         "unicode",
+			chunkSize:    4,
+			chunkOverlap: 1,
+			separators:   []string{" ", ""},
+			text:         "àèìòù ab",
+			expected:     []string{"àèìò", "òù", "ab"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splitter := &textSplitter{
+				chunkSize:    tt.chunkSize,
+				chunkOverlap: tt.chunkOverlap,
+				separators:   tt.separators,
+				length:       countChars,
+			}
+
+			chunks := splitter.split(tt.text)
+			if !reflect.DeepEqual(chunks, tt.expected) {
+				t.Errorf("split %q in %q, expected %q", tt.text, chunks, tt.expected)
+			}
+		})
+	}
+}
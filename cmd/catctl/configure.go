@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"golang.org/x/exp/maps"
+	"golang.org/x/term"
+)
+
+// configureSettings runs the wizard over the properties of the schema, shows the changes
+// and, once confirmed, applies the new settings
+func configureSettings(name string, schema *cat.Schema, current map[string]any, apply func(map[string]any) error) error {
+	if schema == nil {
+		return fmt.Errorf("missing schema of '%s'", name)
+	}
+
+	resolved, err := schema.Resolve()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		current = map[string]any{}
+	}
+
+	title := resolved.HumanReadableName
+	if title == "" {
+		title = resolved.Title
+	}
+	fmt.Printf("Configuring %s (%s)\n", title, name)
+	if resolved.Description != "" {
+		fmt.Println(resolved.Description)
+	}
+	if len(resolved.Properties) == 0 {
+		fmt.Println("no settings to configure")
+		return nil
+	}
+	fmt.Println("Press enter to keep the value in brackets, or type 'null' to clear an optional value.")
+
+	values := map[string]any{}
+	for _, key := range orderedProperties(resolved) {
+		value, set, err := promptProperty(resolved, key, current)
+		if err != nil {
+			return err
+		}
+		if set {
+			values[key] = value
+		}
+	}
+
+	if err := resolved.Validate(values); err != nil {
+		return err
+	}
+
+	changed := changedProperties(current, values)
+	if len(changed) == 0 {
+		fmt.Println("\nno changes")
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(w, "PROPERTY\tCURRENT\tNEW")
+	for _, key := range changed {
+		oldValue, found := current[key]
+		newValue, set := values[key]
+		prop := resolved.Properties[key]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key,
+			displayValue(&prop, key, oldValue, found),
+			displayValue(&prop, key, newValue, set),
+		)
+	}
+	w.Flush()
+	fmt.Println()
+
+	ok, err := confirm("Apply the new settings of '%s'?", name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Println("aborted")
+		return nil
+	}
+
+	if err := apply(values); err != nil {
+		return err
+	}
+	fmt.Println("settings of", name, "updated")
+	return nil
+}
+
+// orderedProperties returns the required properties first, then the optional ones, in alphabetical order
+func orderedProperties(schema *cat.Schema) []string {
+	keys := maps.Keys(schema.Properties)
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := slices.Contains(schema.Required, keys[i]), slices.Contains(schema.Required, keys[j])
+		if ri != rj {
+			return ri
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// promptProperty asks the value of a property until a valid one is typed.
+// It returns false if the property is left unset.
+func promptProperty(schema *cat.Schema, key string, current map[string]any) (any, bool, error) {
+	property := schema.Properties[key]
+	prop := &property
+	required := slices.Contains(schema.Required, key)
+	secret := isSecret(prop, key)
+
+	// the value kept on enter: the current one, or the default
+	fallback, hasFallback := current[key]
+	if !hasFallback && prop.Default != nil {
+		fallback, hasFallback = prop.Default, true
+	}
+
+	fmt.Println()
+	title := prop.Title
+	if title == "" {
+		title = key
+	}
+	fmt.Printf("%s (%s)\n", title, propertyHint(prop, key, required, secret))
+	if prop.Description != "" {
+		fmt.Printf("  %s\n", prop.Description)
+	}
+	if choices := propertyChoices(prop); len(choices) > 0 {
+		fmt.Printf("  choices: %s\n", strings.Join(choices, ", "))
+	}
+
+	for {
+		prompt := key
+		if hasFallback {
+			prompt += " [" + displayValue(prop, key, fallback, true) + "]"
+		}
+
+		input, err := readInput(prompt+": ", secret)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if input == "" {
+			if hasFallback {
+				return fallback, true, nil
+			}
+			if !required {
+				return nil, false, nil
+			}
+			fmt.Println("  a value is required")
+			continue
+		}
+
+		coerced, err := schema.Coerce(map[string]string{key: input})
+		if err != nil {
+			fmt.Printf("  %s\n", err)
+			continue
+		}
+		return coerced[key], true, nil
+	}
+}
+
+func readInput(prompt string, secret bool) (string, error) {
+	fmt.Print(prompt)
+
+	fd := int(os.Stdin.Fd())
+	if secret && term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// propertyHint describes the type and the constraints of a property
+func propertyHint(prop *cat.Schema, key string, required, secret bool) string {
+	hints := []string{key, strings.Join(propertyTypes(prop), " or ")}
+
+	if prop.Minimum != nil {
+		hints = append(hints, "min "+strconv.FormatFloat(*prop.Minimum, 'g', -1, 64))
+	}
+	if prop.Maximum != nil {
+		hints = append(hints, "max "+strconv.FormatFloat(*prop.Maximum, 'g', -1, 64))
+	}
+	if required {
+		hints = append(hints, "required")
+	}
+	if secret {
+		hints = append(hints, "secret")
+	}
+	return strings.Join(hints, ", ")
+}
+
+func propertyTypes(prop *cat.Schema) []string {
+	if prop.Type != "" {
+		return []string{prop.Type}
+	}
+
+	types := []string{}
+	for _, alt := range append(append([]*cat.Schema{}, prop.AnyOf...), prop.OneOf...) {
+		for _, typ := range propertyTypes(alt) {
+			if !slices.Contains(types, typ) {
+				types = append(types, typ)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return []string{"any"}
+	}
+	return types
+}
+
+func propertyChoices(prop *cat.Schema) []string {
+	choices := []string{}
+	for _, value := range prop.Enum {
+		choices = append(choices, fmt.Sprint(value))
+	}
+	for _, alt := range append(append([]*cat.Schema{}, prop.AnyOf...), prop.OneOf...) {
+		choices = append(choices, propertyChoices(alt)...)
+	}
+	return choices
+}
+
+// isSecret checks if the property holds a credential, that is masked when typed and printed
+func isSecret(prop *cat.Schema, key string) bool {
+	if prop.Format == "password" {
+		return true
+	}
+	// matched by word, so that properties like max_tokens are not masked
+	for _, word := range strings.FieldsFunc(strings.ToLower(key), func(r rune) bool { return r == '_' || r == '-' }) {
+		if slices.Contains([]string{"key", "apikey", "token", "secret", "password"}, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func displayValue(prop *cat.Schema, key string, value any, set bool) string {
+	switch {
+	case !set:
+		return "<unset>"
+	case value == nil:
+		return "null"
+	case prop != nil && isSecret(prop, key):
+		return "****"
+	}
+	return fmt.Sprint(value)
+}
+
+// changedProperties returns the sorted keys of the properties with a different value
+func changedProperties(current, values map[string]any) []string {
+	keys := map[string]bool{}
+	for key := range current {
+		keys[key] = true
+	}
+	for key := range values {
+		keys[key] = true
+	}
+
+	changed := []string{}
+	for key := range keys {
+		oldValue, oldFound := current[key]
+		newValue, newFound := values[key]
+		if oldFound != newFound || !reflect.DeepEqual(normalizeNumber(oldValue), normalizeNumber(newValue)) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// normalizeNumber converts the integers to float64, as decoded from JSON, to compare them
+func normalizeNumber(value any) any {
+	switch n := value.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return value
+}
+
+// chooseOne asks to pick one of the options by number or by name
+func chooseOne(title string, options []string, current string) (string, error) {
+	fmt.Println(title)
+	for i, option := range options {
+		marker := " "
+		if option == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %2d) %s\n", marker, i+1, option)
+	}
+
+	for {
+		prompt := "choice"
+		if current != "" {
+			prompt += " [" + current + "]"
+		}
+
+		input, err := readInput(prompt+": ", false)
+		if err != nil {
+			return "", err
+		}
+		if input == "" && current != "" {
+			return current, nil
+		}
+		if i, err := strconv.Atoi(input); err == nil && i >= 1 && i <= len(options) {
+			return options[i-1], nil
+		}
+		if slices.Contains(options, input) {
+			return input, nil
+		}
+		fmt.Println("  invalid choice")
+	}
+}
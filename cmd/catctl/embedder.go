@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var ValidEmbedders = []string{
+	"EmbedderQdrantFastEmbedConfig",
+	"EmbedderOpenAIConfig",
+	"EmbedderAzureOpenAIConfig",
+	"EmbedderGeminiChatConfig",
+	"EmbedderCohereConfig",
+	"EmbedderOpenAICompatibleConfig",
+	"EmbedderLlamaCppConfig",
+	"EmbedderDumbConfig",
+	"EmbedderFakeConfig",
+}
+
+func NewEmbedderCmd(catclient *cat.Client) (*cobra.Command, error) {
+	embedderCmd := &cobra.Command{
+		Use:   "embedder",
+		Short: "manage embedder settings",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	updateCmd, err := NewEmbedderUpdateCmd(catclient)
+	if err != nil {
+		return nil, err
+	}
+
+	embedderCmd.AddCommand(
+		NewEmbedderConfigureCmd(catclient),
+		NewEmbedderGetCmd(catclient),
+		NewEmbedderSwitchCmd(catclient),
+		updateCmd,
+	)
+
+	return embedderCmd, nil
+}
+
+func NewEmbedderGetCmd(catclient *cat.Client) *cobra.Command {
+	embedderGetCmd := &cobra.Command{
+		Use:           "get",
+		Short:         "get embedder settings",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return ValidEmbedders, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				embedderSetting, err := catclient.Embedder.GetByID(context.Background(), args[0])
+				if err != nil {
+					return err
+				}
+
+				y, err := yaml.Marshal(embedderSetting)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(y))
+				return nil
+			}
+
+			embedderSettings, err := catclient.Embedder.GetAll(context.Background())
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("selected_configuration: %s\n\n", embedderSettings.SelectedConfiguration)
+			for _, setting := range embedderSettings.Settings {
+				y, err := yaml.Marshal(setting)
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(y))
+			}
+
+			return nil
+		},
+	}
+
+	return embedderGetCmd
+}
+
+func NewEmbedderUpdateCmd(catclient *cat.Client) (*cobra.Command, error) {
+	type updateCfg struct {
+		keyValues []string
+	}
+
+	cfg := &updateCfg{}
+
+	embedderUpdateCmd := &cobra.Command{
+		Use:           "update",
+		Short:         "update embedder settings",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return ValidEmbedders, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Usage()
+			}
+
+			values, err := parseSetFlags(cfg.keyValues)
+			if err != nil {
+				return err
+			}
+
+			_, err = catclient.Embedder.UpdateValues(context.Background(), args[0], values)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	embedderUpdateCmd.Flags().StringArrayVar(&cfg.keyValues, "set", []string{}, "set key value")
+	err := embedderUpdateCmd.RegisterFlagCompletionFunc("set", completeSetFlags(catclient, &cfg.keyValues, func(ctx context.Context, name string) (*cat.Schema, error) {
+		embedderSetting, err := catclient.Embedder.GetByID(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return embedderSetting.Schema, nil
+	}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return embedderUpdateCmd, nil
+}
+
+func NewEmbedderConfigureCmd(catclient *cat.Client) *cobra.Command {
+	embedderConfigureCmd := &cobra.Command{
+		Use:   "configure [name]",
+		Short: "configure an embedder interactively",
+		Long: `Configure an embedder interactively, prompting for each property of its schema.
+Without a name the embedder is chosen from the available ones.
+
+Switching to a different embedder makes the stored memories unusable: see 'embedder switch'.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return ValidEmbedders, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			settings, err := catclient.Embedder.GetAll(ctx)
+			if err != nil {
+				return err
+			}
+
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+			} else {
+				names := []string{}
+				for _, setting := range settings.Settings {
+					names = append(names, setting.Name)
+				}
+				name, err = chooseOne("Choose the embedder to configure:", names, settings.SelectedConfiguration)
+				if err != nil {
+					return err
+				}
+			}
+
+			if name != settings.SelectedConfiguration {
+				fmt.Printf("warning: the selected embedder is %s, the memories will not be usable with %s\n\n", settings.SelectedConfiguration, name)
+			}
+
+			embedderSetting, err := catclient.Embedder.GetByID(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			return configureSettings(name, embedderSetting.Schema, embedderSetting.Value, func(values map[string]any) error {
+				_, err := catclient.Embedder.Update(ctx, name, values)
+				return err
+			})
+		},
+	}
+
+	return embedderConfigureCmd
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+func NewEmbedderSwitchCmd(catclient *cat.Client) *cobra.Command {
+	type switchCfg struct {
+		keyValues []string
+		exportTo  string
+		yes       bool
+	}
+
+	cfg := &switchCfg{}
+
+	embedderSwitchCmd := &cobra.Command{
+		Use:   "switch <config>",
+		Short: "switch the embedder, re-ingesting the declarative memory",
+		Long: `Switch the embedder of the Cat. The vectors stored in memory are created by the current embedder,
+so they are not usable after the switch.
+
+With --export-to the content of the declarative memory is exported to an archive before the switch,
+and then embedded again with the new embedder. The archive is kept, and it can be imported again
+with 'memory import' if the re-ingestion fails.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return ValidEmbedders, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			target := args[0]
+
+			values, err := parseSetFlags(cfg.keyValues)
+			if err != nil {
+				return err
+			}
+
+			// the values are checked before exporting anything: the --set ones override the stored ones
+			targetSetting, err := catclient.Embedder.GetByID(ctx, target)
+			if err != nil {
+				return err
+			}
+			if targetSetting.Schema == nil {
+				return fmt.Errorf("missing schema of '%s'", target)
+			}
+			overrides, err := targetSetting.Schema.Coerce(values)
+			if err != nil {
+				return err
+			}
+			targetValue := map[string]any{}
+			maps.Copy(targetValue, targetSetting.Value)
+			maps.Copy(targetValue, overrides)
+			if err := targetSetting.Schema.Validate(targetValue); err != nil {
+				return fmt.Errorf("invalid settings of '%s': %w", target, err)
+			}
+
+			settings, err := catclient.Embedder.GetAll(ctx)
+			if err != nil {
+				return err
+			}
+
+			embedder, err := catclient.Memory.Embedder(ctx)
+			if err != nil {
+				return err
+			}
+
+			collections, err := catclient.Memory.Collections(ctx)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("current embedder: %s (%d dimensions)\n\n", settings.SelectedConfiguration, embedder.Size)
+
+			vectors := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "COLLECTION\tVECTORS")
+			for _, collection := range collections {
+				fmt.Fprintf(w, "%s\t%d\n", collection.Name, collection.VectorsCount)
+				vectors += collection.VectorsCount
+			}
+			w.Flush()
+			fmt.Println()
+
+			if !cfg.yes {
+				msg := "The %d vectors in memory will not be usable with '%s'."
+				if cfg.exportTo == "" {
+					msg += " Use --export-to to re-ingest the declarative memory."
+				}
+
+				ok, err := confirm(msg, vectors, target)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Println("aborted")
+					return nil
+				}
+			}
+
+			if cfg.exportTo != "" {
+				f, err := os.Create(cfg.exportTo)
+				if err != nil {
+					return err
+				}
+
+				manifest, err := exportMemory(ctx, catclient, f, memoryExportOpts{
+					collections: []string{"declarative"},
+					pageSize:    100,
+				})
+				if closeErr := f.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					os.Remove(cfg.exportTo)
+					return fmt.Errorf("exporting declarative memory: %w", err)
+				}
+				fmt.Printf("%d declarative points exported to %s\n", manifest.Collections["declarative"], cfg.exportTo)
+			}
+
+			if _, err := catclient.Embedder.Update(ctx, target, targetValue); err != nil {
+				return fmt.Errorf("switching embedder to '%s': %w", target, err)
+			}
+
+			newEmbedder, err := catclient.Memory.Embedder(ctx)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("embedder switched to %s (%d dimensions)\n", target, newEmbedder.Size)
+
+			if cfg.exportTo == "" {
+				return nil
+			}
+
+			// the Cat recreates the collections when the embedder changes, but the stale points are
+			// deleted anyway to avoid duplicates
+			collections, err = catclient.Memory.Collections(ctx)
+			if err != nil {
+				return err
+			}
+			for _, collection := range collections {
+				if collection.Name == "declarative" && collection.VectorsCount > 0 {
+					if err := catclient.Memory.WipeCollection(ctx, "declarative"); err != nil {
+						return fmt.Errorf("deleting stale declarative points: %w", err)
+					}
+				}
+			}
+
+			f, err := os.Open(cfg.exportTo)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			imported, err := importMemory(ctx, catclient, f, memoryImportOpts{
+				collections: []string{"declarative"},
+				reembed:     true,
+			})
+			if err != nil {
+				return fmt.Errorf("re-ingesting declarative memory (the archive is kept in '%s'): %w", cfg.exportTo, err)
+			}
+			fmt.Printf("%d declarative points re-ingested\n", imported["declarative"])
+
+			return nil
+		},
+	}
+
+	embedderSwitchCmd.Flags().StringArrayVar(&cfg.keyValues, "set", []string{}, "set key value of the new embedder")
+	embedderSwitchCmd.Flags().StringVar(&cfg.exportTo, "export-to", "", "Export the declarative memory to this archive and re-ingest it after the switch")
+	embedderSwitchCmd.Flags().BoolVarP(&cfg.yes, "yes", "y", false, "Skip the confirmation")
+
+	return embedderSwitchCmd
+}
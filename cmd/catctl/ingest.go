@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func NewIngestCmd(catclient *cat.Client) *cobra.Command {
+	ingestCmd := &cobra.Command{
+		Use:   "ingest",
+		Short: "ingest documents into the declarative memory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	ingestCmd.AddCommand(
+		NewIngestFileCmd(catclient),
+		NewIngestURLCmd(catclient),
+		NewIngestMemoryCmd(catclient),
+		NewIngestDirCmd(catclient),
+		NewIngestSyncCmd(catclient),
+		NewIngestPreviewCmd(),
+		NewIngestMboxCmd(catclient),
+		NewIngestGitCmd(catclient),
+	)
+
+	return ingestCmd
+}
+
+// uploadCfg holds the ingestion flags shared by the ingest commands
+type uploadCfg struct {
+	chunkSize    int
+	chunkOverlap int
+	metadata     []string
+	userID       string
+	wait         time.Duration
+}
+
+func (c *uploadCfg) addFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&c.chunkSize, "chunk-size", 0, "The size of the chunks (default to the Cat settings)")
+	flags.IntVar(&c.chunkOverlap, "chunk-overlap", 0, "The overlap of the chunks (default to the Cat settings)")
+	flags.StringArrayVar(&c.metadata, "metadata", []string{}, "A metadata of the ingested documents (key=value)")
+	flags.StringVar(&c.userID, "user-id", cat.DefaultUserID, "The user notified about the ingestion")
+	flags.DurationVar(&c.wait, "wait", 0, "Wait up to this duration for the end of each ingestion (i.e. 5m)")
+}
+
+func (c *uploadCfg) uploadOpts() (cat.UploadOpts, error) {
+	metadata, err := parseKeyValues(c.metadata)
+	if err != nil {
+		return cat.UploadOpts{}, err
+	}
+
+	return cat.UploadOpts{
+		ChunkSize:    c.chunkSize,
+		ChunkOverlap: c.chunkOverlap,
+		Metadata:     metadata,
+		UserID:       c.userID,
+		WaitTimeout:  c.wait,
+	}, nil
+}
+
+func NewIngestFileCmd(catclient *cat.Client) *cobra.Command {
+	cfg := &uploadCfg{}
+
+	ingestFileCmd := &cobra.Command{
+		Use:           "file <path>",
+		Short:         "ingest a file",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			resp, err := catclient.RabbitHole.UploadFile(cmd.Context(), filepath.Base(args[0]), f, opts)
+			if err != nil {
+				return fmt.Errorf("uploading file: %w", err)
+			}
+
+			fmt.Printf("%s (%s): %s\n", resp.Filename, resp.ContentType, resp.Info)
+			return nil
+		},
+	}
+
+	cfg.addFlags(ingestFileCmd.Flags())
+
+	return ingestFileCmd
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+func NewIngestDirCmd(catclient *cat.Client) *cobra.Command {
+	type dirCfg struct {
+		uploadCfg
+		include     []string
+		exclude     []string
+		concurrency int
+		summary     string
+		noProgress  bool
+	}
+
+	cfg := &dirCfg{}
+
+	ingestDirCmd := &cobra.Command{
+		Use:   "dir <path>",
+		Short: "ingest all the files of a directory",
+		Long: `Ingest all the files of a directory, and of its subdirectories.
+
+The include and exclude globs are matched against the file name and against its path relative to the directory.
+Files with a MIME type not allowed by the Cat are skipped.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			allowed, err := catclient.RabbitHole.AllowedMimeTypes(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("getting allowed MIME types: %w", err)
+			}
+			allowedSet := mapset.NewSet(allowed...)
+
+			summary := &ingestSummary{}
+
+			paths, err := walkDir(args[0], cfg.include, cfg.exclude)
+			if err != nil {
+				return err
+			}
+
+			files := []cat.FileUpload{}
+			for _, path := range paths {
+				if contentType := cat.ContentType(path); !allowedSet.Contains(contentType) {
+					summary.add(ingestFileSummary{
+						Path:   path,
+						Status: "skipped",
+						Error:  fmt.Sprintf("MIME type '%s' not allowed", contentType),
+					})
+					continue
+				}
+
+				files = append(files, cat.FileUpload{
+					Path: path,
+					Opts: opts,
+				})
+			}
+
+			var bar *progressBar
+			if !cfg.noProgress {
+				bar = newProgressBar(os.Stderr, len(files))
+			}
+
+			start := time.Now()
+			for result := range catclient.RabbitHole.UploadMany(cmd.Context(), files, cfg.concurrency) {
+				fileSummary := ingestFileSummary{
+					Path:  result.File.Path,
+					Bytes: result.Bytes,
+				}
+
+				if result.Err != nil {
+					fileSummary.Status = "failed"
+					fileSummary.Error = result.Err.Error()
+				} else {
+					fileSummary.Status = "uploaded"
+					fileSummary.Info = result.Response.Info
+				}
+
+				summary.add(fileSummary)
+				if bar != nil {
+					bar.update(result.Bytes, result.Err != nil)
+				}
+			}
+			summary.DurationSeconds = time.Since(start).Seconds()
+
+			if bar != nil {
+				bar.done()
+			}
+
+			// stdout is left to the JSON summary, when it's written there
+			out := os.Stdout
+			if cfg.summary == "-" {
+				out = os.Stderr
+			}
+			fmt.Fprintf(out, "%d uploaded, %d failed, %d skipped\n", summary.Uploaded, summary.Failed, summary.Skipped)
+			for _, file := range summary.Files {
+				if file.Status == "failed" {
+					fmt.Fprintf(out, "  %s: %s\n", file.Path, file.Error)
+				}
+			}
+
+			if cfg.summary != "" {
+				if err := summary.write(cfg.summary); err != nil {
+					return fmt.Errorf("writing summary: %w", err)
+				}
+			}
+
+			if summary.Failed > 0 {
+				return fmt.Errorf("%d/%d files failed", summary.Failed, len(files))
+			}
+			return nil
+		},
+	}
+
+	cfg.addFlags(ingestDirCmd.Flags())
+	ingestDirCmd.Flags().StringSliceVar(&cfg.include, "include", []string{}, "The globs of the files to ingest (default all)")
+	ingestDirCmd.Flags().StringSliceVar(&cfg.exclude, "exclude", []string{}, "The globs of the files to skip")
+	ingestDirCmd.Flags().IntVar(&cfg.concurrency, "concurrency", 4, "The number of files uploaded in parallel")
+	ingestDirCmd.Flags().StringVar(&cfg.summary, "summary", "", "Write a JSON summary of the ingestion to this file ('-' for stdout)")
+	ingestDirCmd.Flags().BoolVar(&cfg.noProgress, "no-progress", false, "Hide the progress bar")
+
+	return ingestDirCmd
+}
+
+// walkDir returns the regular files of a directory matching the include globs (if any), and not matching the exclude ones.
+// Hidden directories are skipped.
+func walkDir(root string, include, exclude []string) ([]string, error) {
+	paths := []string{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if len(include) > 0 && !matchAny(include, rel) {
+			return nil
+		}
+		if matchAny(exclude, rel) {
+			return nil
+		}
+
+		paths = append(paths, path)
+		return nil
+	})
+
+	return paths, err
+}
+
+// matchAny checks if any of the globs matches the relative path, or its base name
+func matchAny(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(glob, filepath.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type ingestSummary struct {
+	Uploaded        int                 `json:"uploaded"`
+	Failed          int                 `json:"failed"`
+	Skipped         int                 `json:"skipped"`
+	Bytes           int64               `json:"bytes"`
+	DurationSeconds float64             `json:"duration_seconds"`
+	Files           []ingestFileSummary `json:"files"`
+}
+
+type ingestFileSummary struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Bytes  int64  `json:"bytes,omitempty"`
+	Info   string `json:"info,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (s *ingestSummary) add(file ingestFileSummary) {
+	switch file.Status {
+	case "uploaded":
+		s.Uploaded++
+	case "failed":
+		s.Failed++
+	case "skipped":
+		s.Skipped++
+	}
+	s.Bytes += file.Bytes
+	s.Files = append(s.Files, file)
+}
+
+func (s *ingestSummary) write(filename string) error {
+	var w io.Writer = os.Stdout
+
+	if filename != "-" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// progressBar renders the progress of the uploads on a single line, with the throughput and the failures
+type progressBar struct {
+	w      io.Writer
+	total  int
+	count  int
+	failed int
+	bytes  int64
+	start  time.Time
+}
+
+const progressBarWidth = 30
+
+func newProgressBar(w io.Writer, total int) *progressBar {
+	bar := &progressBar{w: w, total: total, start: time.Now()}
+	bar.render()
+	return bar
+}
+
+func (b *progressBar) update(bytes int64, failed bool) {
+	b.count++
+	b.bytes += bytes
+	if failed {
+		b.failed++
+	}
+	b.render()
+}
+
+func (b *progressBar) render() {
+	filled := progressBarWidth
+	if b.total > 0 {
+		filled = b.count * progressBarWidth / b.total
+	}
+
+	throughput := float64(b.bytes) / time.Since(b.start).Seconds()
+
+	fmt.Fprintf(b.w, "\r[%s%s] %d/%d files  %s/s  %d failed ",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		b.count, b.total, humanBytes(throughput), b.failed,
+	)
+}
+
+func (b *progressBar) done() {
+	fmt.Fprintln(b.w)
+}
+
+func humanBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
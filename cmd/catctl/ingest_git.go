@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+// gitIngestState records the last ingested commit of a repository, and the files ingested with uncommitted changes
+type gitIngestState struct {
+	Repo   string   `json:"repo"`
+	Ref    string   `json:"ref"`
+	Commit string   `json:"commit"`
+	Dirty  []string `json:"dirty,omitempty"`
+}
+
+func NewIngestGitCmd(catclient *cat.Client) *cobra.Command {
+	type gitCfg struct {
+		uploadCfg
+		ref         string
+		repoName    string
+		include     []string
+		exclude     []string
+		stateFile   string
+		concurrency int
+		full        bool
+		dryRun      bool
+	}
+
+	cfg := &gitCfg{}
+
+	ingestGitCmd := &cobra.Command{
+		Use:   "git <repo-path>",
+		Short: "ingest the files of a local git repository",
+		Long: `Ingest the files of a local git repository, from the working tree or from a ref.
+
+Each file is uploaded with its 'repo', 'path' and 'commit' metadata. The files of the working tree with
+uncommitted changes have the commit suffixed with '-dirty'. The last ingested commit is recorded in a state file, and the next runs only ingest the files changed since then, deleting the memories
+of the changed and removed files. The files ingested with uncommitted changes are always ingested again, since
+the changes could have been reverted. Text files with a type not parsed by the Cat are uploaded as plain text.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			repo, err := git(ctx, args[0], "rev-parse", "--show-toplevel")
+			if err != nil {
+				return err
+			}
+			repo = strings.TrimSpace(repo)
+
+			if cfg.repoName == "" {
+				cfg.repoName = filepath.Base(repo)
+			}
+
+			if cfg.stateFile == "" {
+				gitDir, err := git(ctx, repo, "rev-parse", "--absolute-git-dir")
+				if err != nil {
+					return err
+				}
+				cfg.stateFile = filepath.Join(strings.TrimSpace(gitDir), "catctl-ingest.json")
+			}
+
+			commitRef := cfg.ref
+			if commitRef == "" {
+				commitRef = "HEAD"
+			}
+			commit, err := git(ctx, repo, "rev-parse", "--verify", commitRef+"^{commit}")
+			if err != nil {
+				return err
+			}
+			commit = strings.TrimSpace(commit)
+
+			state, err := readGitIngestState(cfg.stateFile)
+			if err != nil {
+				return err
+			}
+			if state != nil && (state.Repo != cfg.repoName || state.Ref != cfg.ref) {
+				return fmt.Errorf("the state file '%s' belongs to repo '%s' at ref '%s'", cfg.stateFile, state.Repo, state.Ref)
+			}
+
+			var changed, removed []string
+			if state == nil || cfg.full {
+				changed, err = gitListFiles(ctx, repo, cfg.ref)
+			} else {
+				changed, removed, err = gitChangedFiles(ctx, repo, state.Commit, cfg.ref)
+			}
+			if err != nil {
+				return err
+			}
+
+			if state != nil && !cfg.full {
+				// the uncommitted changes could have been reverted, leaving the files unchanged since the commit
+				for _, p := range state.Dirty {
+					if !slices.Contains(changed, p) && !slices.Contains(removed, p) {
+						changed = append(changed, p)
+					}
+				}
+				sort.Strings(changed)
+			}
+
+			changed = filterGlobs(changed, cfg.include, cfg.exclude)
+			removed = filterGlobs(removed, cfg.include, cfg.exclude)
+
+			dirty := mapset.NewThreadUnsafeSet[string]()
+			if cfg.ref == "" {
+				uncommitted, err := gitDirtyFiles(ctx, repo)
+				if err != nil {
+					return err
+				}
+				dirty.Append(uncommitted...)
+			}
+
+			allowed, err := catclient.RabbitHole.AllowedMimeTypes(ctx)
+			if err != nil {
+				return fmt.Errorf("getting allowed MIME types: %w", err)
+			}
+
+			files := []cat.FileUpload{}
+			skipped := []string{}
+			ingestedDirty := []string{}
+
+			for _, p := range changed {
+				content, err := gitReadFile(ctx, repo, cfg.ref, p)
+				if errors.Is(err, fs.ErrNotExist) {
+					// deleted from the working tree, but not from the index
+					removed = append(removed, p)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+
+				name := path.Base(p)
+				if !slices.Contains(allowed, cat.ContentType(name)) {
+					if !isText(content) {
+						skipped = append(skipped, p)
+						continue
+					}
+					name += ".txt"
+				}
+
+				fileOpts := opts
+				fileOpts.Metadata = map[string]any{}
+				maps.Copy(fileOpts.Metadata, opts.Metadata)
+				fileOpts.Metadata["source"] = cfg.repoName + "/" + p
+				fileOpts.Metadata["repo"] = cfg.repoName
+				fileOpts.Metadata["path"] = p
+				fileOpts.Metadata["commit"] = commit
+				if dirty.Contains(p) {
+					fileOpts.Metadata["commit"] = commit + "-dirty"
+					ingestedDirty = append(ingestedDirty, p)
+				}
+
+				files = append(files, cat.FileUpload{
+					Name: name,
+					Open: func() (io.ReadCloser, error) {
+						return io.NopCloser(bytes.NewReader(content)), nil
+					},
+					Opts: fileOpts,
+				})
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "ACTION\tPATH")
+			for _, file := range files {
+				fmt.Fprintf(w, "ingest\t%s\n", file.Opts.Metadata["path"])
+			}
+			for _, p := range removed {
+				fmt.Fprintf(w, "delete\t%s\n", p)
+			}
+			for _, p := range skipped {
+				fmt.Fprintf(w, "skip\t%s\n", p)
+			}
+			w.Flush()
+
+			if cfg.dryRun {
+				return nil
+			}
+
+			errs := []error{}
+
+			// the memories of every ingested path are deleted first, so that runs are idempotent
+			toDelete := slices.Clone(removed)
+			for _, file := range files {
+				toDelete = append(toDelete, file.Opts.Metadata["path"].(string))
+			}
+			for _, p := range toDelete {
+				filter := map[string]any{"repo": cfg.repoName, "path": p}
+				if err := catclient.Memory.DeletePointsByMetadata(ctx, "declarative", filter); err != nil {
+					errs = append(errs, fmt.Errorf("deleting memories of '%s': %w", p, err))
+				}
+			}
+
+			if len(errs) == 0 {
+				for result := range catclient.RabbitHole.UploadMany(ctx, files, cfg.concurrency) {
+					if result.Err != nil {
+						errs = append(errs, fmt.Errorf("uploading '%s': %w", result.File.Opts.Metadata["path"], result.Err))
+					}
+				}
+			}
+
+			if len(errs) > 0 {
+				// the state is not updated, so that the next run retries from the same commit
+				return errors.Join(errs...)
+			}
+
+			fmt.Printf("%d files ingested, %d deleted at commit %s\n", len(files), len(removed), commit)
+
+			return writeGitIngestState(cfg.stateFile, &gitIngestState{
+				Repo:   cfg.repoName,
+				Ref:    cfg.ref,
+				Commit: commit,
+				Dirty:  ingestedDirty,
+			})
+		},
+	}
+
+	cfg.addFlags(ingestGitCmd.Flags())
+	ingestGitCmd.Flags().StringVar(&cfg.ref, "ref", "", "The ref to ingest (default the working tree)")
+	ingestGitCmd.Flags().StringVar(&cfg.repoName, "repo-name", "", "The name of the repository in the metadata (default the directory name)")
+	ingestGitCmd.Flags().StringSliceVar(&cfg.include, "include", []string{}, "The globs of the files to ingest (default all)")
+	ingestGitCmd.Flags().StringSliceVar(&cfg.exclude, "exclude", []string{}, "The globs of the files to skip")
+	ingestGitCmd.Flags().StringVar(&cfg.stateFile, "state", "", "The state file (default catctl-ingest.json in the git directory)")
+	ingestGitCmd.Flags().IntVar(&cfg.concurrency, "concurrency", 4, "The number of files uploaded in parallel")
+	ingestGitCmd.Flags().BoolVar(&cfg.full, "full", false, "Ingest all the files, ignoring the last ingested commit")
+	ingestGitCmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Only print the changes, without applying them")
+
+	return ingestGitCmd
+}
+
+func git(ctx context.Context, repo string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// gitListFiles returns the tracked files of the working tree, or the files of the ref
+func gitListFiles(ctx context.Context, repo, ref string) ([]string, error) {
+	var out string
+	var err error
+
+	if ref == "" {
+		out, err = git(ctx, repo, "ls-files", "-z")
+	} else {
+		out, err = git(ctx, repo, "ls-tree", "-r", "-z", "--name-only", ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return splitNul(out), nil
+}
+
+// gitChangedFiles returns the files added or modified, and the ones removed, since the commit.
+// Without a ref the commit is compared with the working tree.
+func gitChangedFiles(ctx context.Context, repo, since, ref string) (changed, removed []string, err error) {
+	args := []string{"diff", "--name-status", "--no-renames", "-z", since}
+	if ref != "" {
+		args = append(args, ref)
+	}
+
+	out, err := git(ctx, repo, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := splitNul(out)
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, p := fields[i], fields[i+1]
+		if status == "D" {
+			removed = append(removed, p)
+		} else {
+			changed = append(changed, p)
+		}
+	}
+
+	sort.Strings(changed)
+	sort.Strings(removed)
+
+	return changed, removed, nil
+}
+
+// gitDirtyFiles returns the files of the working tree with changes not committed yet
+func gitDirtyFiles(ctx context.Context, repo string) ([]string, error) {
+	out, err := git(ctx, repo, "diff", "--name-only", "--no-renames", "-z", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// gitReadFile reads a file from the working tree, or from the ref
+func gitReadFile(ctx context.Context, repo, ref, p string) ([]byte, error) {
+	if ref == "" {
+		return os.ReadFile(filepath.Join(repo, filepath.FromSlash(p)))
+	}
+
+	out, err := git(ctx, repo, "show", ref+":"+p)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+func splitNul(s string) []string {
+	fields := []string{}
+	for _, field := range strings.Split(s, "\x00") {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func filterGlobs(paths, include, exclude []string) []string {
+	filtered := []string{}
+	for _, p := range paths {
+		if len(include) > 0 && !matchAny(include, p) {
+			continue
+		}
+		if matchAny(exclude, p) {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// isText checks if the content looks like UTF-8 text
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.ContainsRune(content, 0)
+}
+
+func readGitIngestState(filename string) (*gitIngestState, error) {
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &gitIngestState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid state '%s': %w", filename, err)
+	}
+	return state, nil
+}
+
+func writeGitIngestState(filename string, state *gitIngestState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0o644)
+}
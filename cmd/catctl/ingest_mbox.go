@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+func NewIngestMboxCmd(catclient *cat.Client) *cobra.Command {
+	type mboxCfg struct {
+		uploadCfg
+		since       string
+		until       string
+		addresses   []string
+		concurrency int
+		dryRun      bool
+	}
+
+	cfg := &mboxCfg{}
+
+	ingestMboxCmd := &cobra.Command{
+		Use:   "mbox <file>",
+		Short: "ingest the threads of an mbox archive",
+		Long: `Ingest the threads of an mbox archive. Each thread is uploaded as a text document,
+with the subject, participants, date and message id of the thread as metadata.
+
+The messages can be filtered by date and by address (of the sender or of the recipients).`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			since, err := parseDate(cfg.since)
+			if err != nil {
+				return err
+			}
+			until, err := parseDate(cfg.until)
+			if err != nil {
+				return err
+			}
+			if !until.IsZero() {
+				// the until date is inclusive
+				until = until.AddDate(0, 0, 1)
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			messages, skipped, err := readMbox(f)
+			if err != nil {
+				return fmt.Errorf("reading mbox: %w", err)
+			}
+			for _, err := range skipped {
+				fmt.Fprintf(os.Stderr, "skipping %s\n", err)
+			}
+
+			filtered := []*mboxMessage{}
+			for _, msg := range messages {
+				if !since.IsZero() && msg.Date.Before(since) {
+					continue
+				}
+				if !until.IsZero() && !msg.Date.Before(until) {
+					continue
+				}
+				if len(cfg.addresses) > 0 && !hasAnyAddress(msg, cfg.addresses) {
+					continue
+				}
+				filtered = append(filtered, msg)
+			}
+
+			threads := groupThreads(filtered)
+			fmt.Printf("%d messages, %d matching, %d threads, %d malformed skipped\n", len(messages), len(filtered), len(threads), len(skipped))
+
+			mboxName := filepath.Base(args[0])
+
+			files := []cat.FileUpload{}
+			for _, thread := range threads {
+				thread := thread
+				first := thread.Messages[0]
+
+				fileOpts := opts
+				fileOpts.Metadata = map[string]any{}
+				maps.Copy(fileOpts.Metadata, opts.Metadata)
+				fileOpts.Metadata["source"] = mboxName + "#" + thread.ID
+				fileOpts.Metadata["message_id"] = thread.ID
+				fileOpts.Metadata["subject"] = thread.Subject
+				fileOpts.Metadata["from"] = formatAddress(first.From)
+				fileOpts.Metadata["participants"] = strings.Join(thread.participants(), ", ")
+				fileOpts.Metadata["date"] = first.Date.Format(time.RFC3339)
+				fileOpts.Metadata["messages"] = len(thread.Messages)
+
+				files = append(files, cat.FileUpload{
+					Name: threadFilename(thread),
+					Open: func() (io.ReadCloser, error) {
+						return io.NopCloser(strings.NewReader(thread.document())), nil
+					},
+					Opts: fileOpts,
+				})
+			}
+
+			if cfg.dryRun {
+				w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+				fmt.Fprintln(w, "DATE\tMESSAGES\tFROM\tSUBJECT")
+				for _, thread := range threads {
+					first := thread.Messages[0]
+					fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", first.Date.Format(time.DateOnly), len(thread.Messages), first.From.Address, truncate(thread.Subject, 60))
+				}
+				return w.Flush()
+			}
+
+			failed := 0
+			for result := range catclient.RabbitHole.UploadMany(cmd.Context(), files, cfg.concurrency) {
+				if result.Err != nil {
+					failed++
+					fmt.Printf("%s: %s\n", result.File.Opts.Metadata["subject"], result.Err)
+				}
+			}
+
+			fmt.Printf("%d threads uploaded, %d failed\n", len(files)-failed, failed)
+			if len(skipped) > 0 {
+				fmt.Printf("%d malformed messages skipped\n", len(skipped))
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d/%d threads failed", failed, len(files))
+			}
+			return nil
+		},
+	}
+
+	cfg.addFlags(ingestMboxCmd.Flags())
+	ingestMboxCmd.Flags().StringVar(&cfg.since, "since", "", "Ingest only the messages sent from this date (YYYY-MM-DD)")
+	ingestMboxCmd.Flags().StringVar(&cfg.until, "until", "", "Ingest only the messages sent up to this date (YYYY-MM-DD)")
+	ingestMboxCmd.Flags().StringSliceVar(&cfg.addresses, "address", []string{}, "Ingest only the messages sent from or to these addresses")
+	ingestMboxCmd.Flags().IntVar(&cfg.concurrency, "concurrency", 4, "The number of threads uploaded in parallel")
+	ingestMboxCmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Only list the threads, without uploading them")
+
+	return ingestMboxCmd
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s': expected YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+func hasAnyAddress(msg *mboxMessage, addresses []string) bool {
+	msgAddresses := []*mail.Address{msg.From}
+	msgAddresses = append(msgAddresses, msg.To...)
+	msgAddresses = append(msgAddresses, msg.Cc...)
+
+	for _, address := range addresses {
+		for _, msgAddress := range msgAddresses {
+			if strings.EqualFold(address, msgAddress.Address) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var nonFilenameChars = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// threadFilename returns a readable text filename from the date and the subject of the thread
+func threadFilename(thread *mboxThread) string {
+	subject := strings.Trim(nonFilenameChars.ReplaceAllString(strings.ToLower(thread.Subject), "-"), "-")
+	subject = string([]rune(subject)[:min(len([]rune(subject)), 60)])
+	if subject == "" {
+		subject = "no-subject"
+	}
+	return thread.Messages[0].Date.Format(time.DateOnly) + "-" + subject + ".txt"
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+func NewIngestMemoryCmd(catclient *cat.Client) *cobra.Command {
+	type memoryCfg struct {
+		ignoreEmbedderName bool
+	}
+
+	cfg := &memoryCfg{}
+
+	ingestMemoryCmd := &cobra.Command{
+		Use:   "memory <file.json>",
+		Short: "upload a file of exported declarative memories",
+		Long: `Upload a file of declarative memories, exported from another Cat.
+
+The structure of the file and the compatibility of its embedder are checked before sending it.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			memoryFile := &cat.MemoryFile{}
+			if err := json.NewDecoder(f).Decode(memoryFile); err != nil {
+				return fmt.Errorf("invalid memory file: %w", err)
+			}
+			if err := memoryFile.Validate(); err != nil {
+				return fmt.Errorf("invalid memory file: %w", err)
+			}
+
+			err = checkEmbedderCompatibility(cmd.Context(), catclient, memoryFile.Embedder, cfg.ignoreEmbedderName)
+			if err != nil {
+				return err
+			}
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+
+			resp, err := catclient.RabbitHole.UploadMemory(cmd.Context(), f)
+			if err != nil {
+				return fmt.Errorf("uploading memory: %w", err)
+			}
+
+			fmt.Printf("%d declarative memories uploaded: %s\n", len(memoryFile.Collections["declarative"]), resp.Info)
+			return nil
+		},
+	}
+
+	ingestMemoryCmd.Flags().BoolVar(&cfg.ignoreEmbedderName, "ignore-embedder-name", false, "Upload the memories even if they were created by a different embedder with the same size")
+
+	return ingestMemoryCmd
+}
+
+// checkEmbedderCompatibility checks that vectors created by the provided embedder can be stored in the Cat memory
+func checkEmbedderCompatibility(ctx context.Context, catclient *cat.Client, embedder cat.EmbedderInfo, ignoreName bool) error {
+	current, err := catclient.Memory.Embedder(ctx)
+	if err != nil {
+		return fmt.Errorf("getting Cat embedder: %w", err)
+	}
+
+	if embedder.Size != current.Size {
+		return fmt.Errorf("vectors have %d dimensions (%s) but the Cat embedder has %d (%s)",
+			embedder.Size, embedder.Name, current.Size, current.Name,
+		)
+	}
+
+	if embedder.Name != current.Name && !ignoreName {
+		return fmt.Errorf("vectors were created by %s but the Cat embedder is %s", embedder.Name, current.Name)
+	}
+
+	return nil
+}
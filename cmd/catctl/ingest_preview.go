@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+func NewIngestPreviewCmd() *cobra.Command {
+	type previewCfg struct {
+		chunkSize    int
+		chunkOverlap int
+		unit         string
+		samples      int
+		buckets      int
+	}
+
+	cfg := &previewCfg{}
+
+	ingestPreviewCmd := &cobra.Command{
+		Use:   "preview <file>",
+		Short: "preview locally the chunks of a text or Markdown file",
+		Long: `Split a plain text or Markdown file locally, with the same recursive strategy used by the Cat,
+and print the number of chunks, an histogram of their sizes and some samples.
+
+The Cat measures the chunks in tokens of the cl100k_base encoding: the 'tokens' unit approximates them.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfg.chunkSize <= 0 || cfg.chunkOverlap < 0 || cfg.chunkOverlap >= cfg.chunkSize {
+				return fmt.Errorf("invalid chunk size %d with overlap %d", cfg.chunkSize, cfg.chunkOverlap)
+			}
+
+			var length func(string) int
+			switch cfg.unit {
+			case "tokens":
+				length = countTokens
+			case "chars":
+				length = countChars
+			default:
+				return fmt.Errorf("unknown unit '%s'", cfg.unit)
+			}
+
+			contentType := cat.ContentType(args[0])
+			if contentType != "text/plain" && contentType != "text/markdown" {
+				fmt.Fprintf(os.Stderr, "warning: %s is parsed by the Cat before chunking, the preview could differ\n", contentType)
+			}
+
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			splitter := &textSplitter{
+				chunkSize:    cfg.chunkSize,
+				chunkOverlap: cfg.chunkOverlap,
+				separators:   catSeparators,
+				length:       length,
+			}
+			chunks := splitter.split(string(b))
+
+			fmt.Printf("%d chunks (size %d, overlap %d %s)\n", len(chunks), cfg.chunkSize, cfg.chunkOverlap, cfg.unit)
+			if len(chunks) == 0 {
+				return nil
+			}
+
+			sizes := make([]int, len(chunks))
+			minSize, maxSize, sum := 0, 0, 0
+			for i, chunk := range chunks {
+				sizes[i] = length(chunk)
+				if i == 0 || sizes[i] < minSize {
+					minSize = sizes[i]
+				}
+				maxSize = max(maxSize, sizes[i])
+				sum += sizes[i]
+			}
+			fmt.Printf("min %d, avg %d, max %d %s\n\n", minSize, sum/len(chunks), maxSize, cfg.unit)
+
+			printHistogram(sizes, max(cfg.chunkSize, maxSize), cfg.buckets)
+
+			for i := 0; i < cfg.samples && i < len(chunks); i++ {
+				fmt.Printf("\n--- chunk %d (%d %s) ---\n%s\n", i+1, sizes[i], cfg.unit, chunks[i])
+			}
+
+			return nil
+		},
+	}
+
+	ingestPreviewCmd.Flags().IntVar(&cfg.chunkSize, "chunk-size", 256, "The size of the chunks")
+	ingestPreviewCmd.Flags().IntVar(&cfg.chunkOverlap, "chunk-overlap", 64, "The overlap of the chunks")
+	ingestPreviewCmd.Flags().StringVar(&cfg.unit, "unit", "tokens", "The unit of the sizes (tokens, chars)")
+	ingestPreviewCmd.Flags().IntVar(&cfg.samples, "samples", 3, "The number of sample chunks to print")
+	ingestPreviewCmd.Flags().IntVar(&cfg.buckets, "buckets", 8, "The number of buckets of the size histogram")
+
+	return ingestPreviewCmd
+}
+
+// printHistogram prints the distribution of the sizes in buckets of the same width, from 0 to upTo
+func printHistogram(sizes []int, upTo, buckets int) {
+	if buckets < 1 {
+		buckets = 1
+	}
+	width := (upTo + buckets - 1) / buckets
+	if width < 1 {
+		width = 1
+	}
+
+	counts := make([]int, buckets)
+	maxCount := 0
+	for _, size := range sizes {
+		i := min(size/width, buckets-1)
+		counts[i]++
+		maxCount = max(maxCount, counts[i])
+	}
+
+	const barWidth = 40
+	for i, count := range counts {
+		bar := strings.Repeat("#", count*barWidth/maxCount)
+		fmt.Printf("%5d-%-5d | %-*s %d\n", i*width, (i+1)*width-1, barWidth, bar, count)
+	}
+}
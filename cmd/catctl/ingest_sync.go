@@ -0,0 +1,247 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"text/tabwriter"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+const defaultSyncStateFilename = ".catctl-sync.json"
+
+// syncState maps the path of each ingested file, relative to the synced directory, to the hash of its content
+type syncState struct {
+	Files map[string]string `json:"files"`
+}
+
+func NewIngestSyncCmd(catclient *cat.Client) *cobra.Command {
+	type syncCfg struct {
+		uploadCfg
+		include     []string
+		exclude     []string
+		concurrency int
+		stateFile   string
+		dryRun      bool
+	}
+
+	cfg := &syncCfg{}
+
+	ingestSyncCmd := &cobra.Command{
+		Use:   "sync <dir>",
+		Short: "mirror a directory into the declarative memory",
+		Long: `Mirror a directory into the declarative memory, like rsync.
+
+A state file keeps the content hash of each ingested file. Only new and changed files are uploaded,
+tagged with their 'source' path and 'hash' metadata, while the declarative memories of changed
+and removed files are deleted.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := args[0]
+
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			if cfg.stateFile == "" {
+				cfg.stateFile = filepath.Join(dir, defaultSyncStateFilename)
+			}
+
+			state, err := readSyncState(cfg.stateFile)
+			if err != nil {
+				return err
+			}
+
+			allowed, err := catclient.RabbitHole.AllowedMimeTypes(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("getting allowed MIME types: %w", err)
+			}
+
+			paths, err := walkDir(dir, cfg.include, append(cfg.exclude, filepath.Base(cfg.stateFile)))
+			if err != nil {
+				return err
+			}
+
+			current := map[string]string{}
+			for _, path := range paths {
+				if !slices.Contains(allowed, cat.ContentType(path)) {
+					continue
+				}
+
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+
+				hash, err := hashFile(path)
+				if err != nil {
+					return err
+				}
+				current[filepath.ToSlash(rel)] = hash
+			}
+
+			added, changed, removed := diffSyncState(state.Files, current)
+			if len(added)+len(changed)+len(removed) == 0 {
+				fmt.Println("memory is already in sync")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "ACTION\tPATH")
+			for _, rel := range added {
+				fmt.Fprintf(w, "add\t%s\n", rel)
+			}
+			for _, rel := range changed {
+				fmt.Fprintf(w, "update\t%s\n", rel)
+			}
+			for _, rel := range removed {
+				fmt.Fprintf(w, "delete\t%s\n", rel)
+			}
+			w.Flush()
+
+			if cfg.dryRun {
+				return nil
+			}
+
+			// the state is saved with the successful changes, even if some of them failed
+			defer func() {
+				if err := writeSyncState(cfg.stateFile, state); err != nil {
+					fmt.Fprintln(os.Stderr, "writing sync state:", err)
+				}
+			}()
+
+			errs := []error{}
+
+			for _, rel := range append(changed, removed...) {
+				filter := map[string]any{"source": rel, "hash": state.Files[rel]}
+				if err := catclient.Memory.DeletePointsByMetadata(cmd.Context(), "declarative", filter); err != nil {
+					errs = append(errs, fmt.Errorf("deleting memories of '%s': %w", rel, err))
+					continue
+				}
+				delete(state.Files, rel)
+			}
+
+			files := []cat.FileUpload{}
+			for _, rel := range append(added, changed...) {
+				if _, found := state.Files[rel]; found {
+					// old memories not deleted, skip the upload to avoid duplicates
+					continue
+				}
+
+				fileOpts := opts
+				fileOpts.Metadata = map[string]any{}
+				maps.Copy(fileOpts.Metadata, opts.Metadata)
+				fileOpts.Metadata["source"] = rel
+				fileOpts.Metadata["hash"] = current[rel]
+
+				files = append(files, cat.FileUpload{
+					Name: filepath.Base(rel),
+					Path: filepath.Join(dir, filepath.FromSlash(rel)),
+					Opts: fileOpts,
+				})
+			}
+
+			for result := range catclient.RabbitHole.UploadMany(cmd.Context(), files, cfg.concurrency) {
+				rel := result.File.Opts.Metadata["source"].(string)
+				if result.Err != nil {
+					errs = append(errs, fmt.Errorf("uploading '%s': %w", rel, result.Err))
+					continue
+				}
+				state.Files[rel] = current[rel]
+			}
+
+			return errors.Join(errs...)
+		},
+	}
+
+	cfg.addFlags(ingestSyncCmd.Flags())
+	ingestSyncCmd.Flags().StringSliceVar(&cfg.include, "include", []string{}, "The globs of the files to sync (default all)")
+	ingestSyncCmd.Flags().StringSliceVar(&cfg.exclude, "exclude", []string{}, "The globs of the files to skip")
+	ingestSyncCmd.Flags().IntVar(&cfg.concurrency, "concurrency", 4, "The number of files uploaded in parallel")
+	ingestSyncCmd.Flags().StringVar(&cfg.stateFile, "state", "", "The state file (default <dir>/"+defaultSyncStateFilename+")")
+	ingestSyncCmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Only print the changes, without applying them")
+
+	return ingestSyncCmd
+}
+
+// diffSyncState compares the synced hashes with the current ones, returning the sorted added, changed and removed paths
+func diffSyncState(synced, current map[string]string) (added, changed, removed []string) {
+	for rel, hash := range current {
+		syncedHash, found := synced[rel]
+		switch {
+		case !found:
+			added = append(added, rel)
+		case syncedHash != hash:
+			changed = append(changed, rel)
+		}
+	}
+
+	for rel := range synced {
+		if _, found := current[rel]; !found {
+			removed = append(removed, rel)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(changed)
+	sort.Strings(removed)
+
+	return added, changed, removed
+}
+
+func readSyncState(filename string) (*syncState, error) {
+	state := &syncState{Files: map[string]string{}}
+
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid sync state '%s': %w", filename, err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]string{}
+	}
+
+	return state, nil
+}
+
+func writeSyncState(filename string, state *syncState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b, 0o644)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+func NewIngestURLCmd(catclient *cat.Client) *cobra.Command {
+	type urlCfg struct {
+		uploadCfg
+		filename    string
+		sitemap     string
+		concurrency int
+	}
+
+	cfg := &urlCfg{}
+
+	ingestURLCmd := &cobra.Command{
+		Use:   "url [url...]",
+		Short: "ingest web pages",
+		Long: `Ingest web pages, listed as arguments, in a file (one URL for each line) or in a sitemap.
+
+The sitemap can be a local file or a URL, and it is parsed locally. Sitemap indexes are followed.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts, err := cfg.uploadOpts()
+			if err != nil {
+				return err
+			}
+
+			urls := args
+
+			if cfg.filename != "" {
+				fileURLs, err := readURLsFile(cfg.filename)
+				if err != nil {
+					return err
+				}
+				urls = append(urls, fileURLs...)
+			}
+
+			if cfg.sitemap != "" {
+				sitemapURLs, err := readSitemap(cmd.Context(), cfg.sitemap)
+				if err != nil {
+					return fmt.Errorf("reading sitemap: %w", err)
+				}
+				urls = append(urls, sitemapURLs...)
+			}
+
+			// the same page could be listed more than once
+			seen := mapset.NewThreadUnsafeSet[string]()
+			urls = slices.DeleteFunc(slices.Clone(urls), func(u string) bool { return !seen.Add(u) })
+			if len(urls) == 0 {
+				return fmt.Errorf("no URLs to ingest")
+			}
+
+			results := uploadURLs(cmd.Context(), catclient, urls, opts, cfg.concurrency)
+
+			failed := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "URL\tSTATUS\tINFO")
+			for _, result := range results {
+				if result.err != nil {
+					failed++
+					fmt.Fprintf(w, "%s\tfailed\t%s\n", result.url, result.err)
+					continue
+				}
+				fmt.Fprintf(w, "%s\tok\t%s\n", result.url, result.info)
+			}
+			w.Flush()
+
+			if failed > 0 {
+				return fmt.Errorf("%d/%d URLs failed", failed, len(urls))
+			}
+			return nil
+		},
+	}
+
+	cfg.addFlags(ingestURLCmd.Flags())
+	ingestURLCmd.Flags().StringVarP(&cfg.filename, "filename", "f", "", "A file with the URLs to ingest, one for each line")
+	ingestURLCmd.Flags().StringVar(&cfg.sitemap, "sitemap", "", "The path or the URL of a sitemap.xml")
+	ingestURLCmd.Flags().IntVar(&cfg.concurrency, "concurrency", 4, "The number of URLs submitted in parallel")
+
+	return ingestURLCmd
+}
+
+type urlResult struct {
+	url  string
+	info string
+	err  error
+}
+
+// uploadURLs submits the URLs with a bounded number of workers. The results are in the same order of the URLs.
+func uploadURLs(ctx context.Context, catclient *cat.Client, urls []string, opts cat.UploadOpts, concurrency int) []urlResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]urlResult, len(urls))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i].url = urls[i]
+
+				resp, err := catclient.RabbitHole.UploadURL(ctx, urls[i], opts)
+				if err != nil {
+					results[i].err = err
+					continue
+				}
+				results[i].info = resp.Info
+			}
+		}()
+	}
+
+	for i := range urls {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// readURLsFile reads a URL for each line, skipping empty lines and comments
+func readURLsFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	urls := []string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	return urls, scanner.Err()
+}
+
+type sitemap struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// maxSitemapDepth is the max nesting of the sitemap indexes that are followed
+const maxSitemapDepth = 5
+
+// readSitemap returns the page URLs of a sitemap, following the nested sitemaps of a sitemap index.
+// Every sitemap is read once, and the duplicated URLs are skipped.
+func readSitemap(ctx context.Context, location string) ([]string, error) {
+	urls := []string{}
+	err := walkSitemap(ctx, location, 0, mapset.NewThreadUnsafeSet[string](), mapset.NewThreadUnsafeSet[string](), &urls)
+	return urls, err
+}
+
+func walkSitemap(ctx context.Context, location string, depth int, visited, seen mapset.Set[string], urls *[]string) error {
+	if depth > maxSitemapDepth {
+		return fmt.Errorf("sitemap '%s' is nested more than %d levels", location, maxSitemapDepth)
+	}
+	if !visited.Add(location) {
+		return nil
+	}
+
+	r, err := openLocation(ctx, location)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	sm := &sitemap{}
+	if err := xml.NewDecoder(r).Decode(sm); err != nil {
+		return fmt.Errorf("invalid sitemap '%s': %w", location, err)
+	}
+
+	for _, u := range sm.URLs {
+		if loc := strings.TrimSpace(u.Loc); loc != "" && seen.Add(loc) {
+			*urls = append(*urls, loc)
+		}
+	}
+
+	for _, nested := range sm.Sitemaps {
+		if err := walkSitemap(ctx, strings.TrimSpace(nested.Loc), depth+1, visited, seen, urls); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openLocation opens a local file, or downloads it if the location is a http(s) URL
+func openLocation(ctx context.Context, location string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.Open(location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode > 399 {
+		res.Body.Close()
+		return nil, fmt.Errorf("downloading '%s': %s", location, res.Status)
+	}
+
+	return res.Body, nil
+}
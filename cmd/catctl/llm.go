@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	cat "github.com/enrichman/ccat-client-go"
@@ -12,6 +16,7 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ValidLLMs are the LLMs of the Cat core, used only when the Cat is unreachable
 var ValidLLMs = []string{
 	"LLMOpenAIChatConfig",
 	"LLMOpenAIConfig",
@@ -42,49 +47,61 @@ func NewLLMCmd(catclient *cat.Client) (*cobra.Command, error) {
 	}
 
 	llmCmd.AddCommand(
+		NewLLMCompareCmd(catclient),
+		NewLLMConfigureCmd(catclient),
 		NewLLMGetCmd(catclient),
+		NewLLMProfileCmd(catclient),
+		NewLLMTestCmd(catclient),
 		updateCmd,
+		NewLLMUseCmd(catclient),
 	)
 
+	// the command groups keep the default help, that lists their subcommands
+	for _, cmd := range llmCmd.Commands() {
+		if !cmd.HasSubCommands() {
+			withLLMNamesHelp(cmd, catclient)
+		}
+	}
+
 	return llmCmd, nil
 }
 
 func NewLLMGetCmd(catclient *cat.Client) *cobra.Command {
 	llmGetCmd := &cobra.Command{
-		Use:           "get",
-		Short:         "get LLM settings",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		Args:          cobra.MaximumNArgs(1),
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) == 1 {
-				return []string{}, cobra.ShellCompDirectiveNoFileComp
-			}
-			return ValidLLMs, cobra.ShellCompDirectiveNoFileComp
-		},
+		Use:               "get",
+		Short:             "get LLM settings",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeLLMNames(catclient),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			llmSettings := []*cat.LLMSetting{}
+			settings, err := catclient.LLM.GetAll(context.Background())
+			if err != nil {
+				return err
+			}
+			llmSettings := settings.Settings
 
 			if len(args) == 1 {
-				llmSetting, err := catclient.LLM.GetByID(context.Background(), args[0])
-				if err != nil {
+				if err := validateLLMName(cmd.Context(), catclient, args[0]); err != nil {
 					return err
 				}
-				llmSettings = append(llmSettings, llmSetting)
 
-			} else {
-				setts, err := catclient.LLM.Get(context.Background())
+				llmSetting, err := catclient.LLM.GetByID(context.Background(), args[0])
 				if err != nil {
 					return err
 				}
-				llmSettings = setts
+				llmSettings = []*cat.LLMSetting{llmSetting}
 			}
 
+			fmt.Printf("selected_configuration: %s\n\n", settings.SelectedConfiguration)
 			for _, setting := range llmSettings {
 				y, err := yaml.Marshal(setting)
 				if err != nil {
 					return err
 				}
+				if setting.Name == settings.SelectedConfiguration {
+					fmt.Println("# selected")
+				}
 				fmt.Println(string(y))
 			}
 
@@ -103,32 +120,26 @@ func NewLLMUpdateCmd(catclient *cat.Client) (*cobra.Command, error) {
 	cfg := &updateCfg{}
 
 	llmUpdateCmd := &cobra.Command{
-		Use:           "update",
-		Short:         "update LLM settings",
-		SilenceUsage:  true,
-		SilenceErrors: true,
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) == 1 {
-				return []string{}, cobra.ShellCompDirectiveNoFileComp
-			}
-			return ValidLLMs, cobra.ShellCompDirectiveNoFileComp
-		},
+		Use:               "update",
+		Short:             "update LLM settings",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		ValidArgsFunction: completeLLMNames(catclient),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return cmd.Usage()
 			}
 
-			updateRequest := map[string]any{}
+			if err := validateLLMName(cmd.Context(), catclient, args[0]); err != nil {
+				return err
+			}
 
-			for _, keyValue := range cfg.keyValues {
-				kVal := strings.Split(keyValue, "=")
-				if len(kVal) == 1 {
-					return fmt.Errorf("invalid set flag for '%s': missing value", keyValue)
-				}
-				updateRequest[kVal[0]] = kVal[1]
+			values, err := parseSetFlags(cfg.keyValues)
+			if err != nil {
+				return err
 			}
 
-			_, err := catclient.LLM.Update(context.Background(), args[0], updateRequest)
+			_, err = catclient.LLM.UpdateValues(context.Background(), args[0], values)
 			if err != nil {
 				return err
 			}
@@ -138,27 +149,218 @@ func NewLLMUpdateCmd(catclient *cat.Client) (*cobra.Command, error) {
 	}
 
 	llmUpdateCmd.Flags().StringArrayVar(&cfg.keyValues, "set", []string{}, "set key value")
-	err := llmUpdateCmd.RegisterFlagCompletionFunc("set", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		llmSetting, err := catclient.LLM.GetByID(context.Background(), args[0])
+	err := llmUpdateCmd.RegisterFlagCompletionFunc("set", completeSetFlags(catclient, &cfg.keyValues, func(ctx context.Context, name string) (*cat.Schema, error) {
+		llmSetting, err := catclient.LLM.GetByID(ctx, name)
 		if err != nil {
+			return nil, err
+		}
+		return llmSetting.Schema, nil
+	}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return llmUpdateCmd, nil
+}
+
+// parseSetFlags splits the key=value pairs of the --set flags on the first '=', so that values can contain it
+func parseSetFlags(keyValues []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, keyValue := range keyValues {
+		key, value, found := strings.Cut(keyValue, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid set flag for '%s': missing value", keyValue)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// completeSetFlags completes the --set flags with the properties of the schema of the settings in the first argument,
+// that are not set yet
+func completeSetFlags(catclient *cat.Client, keyValues *[]string, schema func(ctx context.Context, name string) (*cat.Schema, error)) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 || initClient(cmd) != nil {
 			return []string{}, cobra.ShellCompDirectiveNoFileComp
 		}
 
-		availableProps := maps.Keys(llmSetting.Schema.Properties)
-		availablePropsSet := mapset.NewSet(availableProps...)
+		s, err := schema(cmd.Context(), args[0])
+		if err != nil || s == nil {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		availablePropsSet := mapset.NewSet(maps.Keys(s.Properties)...)
 
 		existingValues := mapset.NewSet[string]()
-		for _, kv := range cfg.keyValues {
-			existingValues.Add(strings.Split(kv, "=")[0])
+		for _, kv := range *keyValues {
+			key, _, _ := strings.Cut(kv, "=")
+			existingValues.Add(key)
 		}
 		available := availablePropsSet.Difference(existingValues).ToSlice()
 
 		return available, cobra.ShellCompDirectiveNoSpace
-	})
+	}
+}
 
-	if err != nil {
-		return nil, err
+func NewLLMConfigureCmd(catclient *cat.Client) *cobra.Command {
+	llmConfigureCmd := &cobra.Command{
+		Use:   "configure [name]",
+		Short: "configure an LLM interactively",
+		Long: `Configure an LLM interactively, prompting for each property of its schema.
+Without a name the LLM is chosen from the available ones.`,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeLLMNames(catclient),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+				if err := validateLLMName(ctx, catclient, name); err != nil {
+					return err
+				}
+			} else {
+				names, _ := llmProviders(ctx, catclient, true)
+
+				var err error
+				name, err = chooseOne("Choose the LLM to configure:", names, "")
+				if err != nil {
+					return err
+				}
+			}
+
+			llmSetting, err := catclient.LLM.GetByID(ctx, name)
+			if err != nil {
+				return err
+			}
+
+			return configureSettings(name, llmSetting.Schema, llmSetting.Value, func(values map[string]any) error {
+				_, err := catclient.LLM.Update(ctx, name, values)
+				return err
+			})
+		},
 	}
 
-	return llmUpdateCmd, nil
+	return llmConfigureCmd
+}
+
+func NewLLMUseCmd(catclient *cat.Client) *cobra.Command {
+	llmUseCmd := &cobra.Command{
+		Use:               "use <name>",
+		Short:             "switch to an LLM, with its stored settings",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeLLMNames(catclient),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if err := validateLLMName(ctx, catclient, args[0]); err != nil {
+				return err
+			}
+
+			settings, err := catclient.LLM.GetAll(ctx)
+			if err != nil {
+				return err
+			}
+			if settings.SelectedConfiguration == args[0] {
+				fmt.Println(args[0], "is already selected")
+				return nil
+			}
+
+			if _, err := catclient.LLM.Use(ctx, args[0]); err != nil {
+				return fmt.Errorf("%w: configure it with 'catctl llm configure %s'", err, args[0])
+			}
+
+			fmt.Printf("switched from %s to %s\n", settings.SelectedConfiguration, args[0])
+			return nil
+		},
+	}
+
+	return llmUseCmd
+}
+
+func NewLLMTestCmd(catclient *cat.Client) *cobra.Command {
+	type testCfg struct {
+		message  string
+		userID   string
+		timeout  time.Duration
+		rollback bool
+	}
+
+	cfg := &testCfg{}
+
+	llmTestCmd := &cobra.Command{
+		Use:   "test [name]",
+		Short: "check that the LLM replies to a probe message",
+		Long: `Send a probe message to the selected LLM through the chat, and report the time to the first token,
+the total latency and the reply. Error frames of the Cat and empty replies make the test fail.
+
+With a name, the LLM is selected with its stored settings before the test, and with --rollback
+the previous one is selected again if the test fails.`,
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeLLMNames(catclient),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			settings, err := catclient.LLM.GetAll(ctx)
+			if err != nil {
+				return err
+			}
+			previous := settings.SelectedConfiguration
+
+			name := previous
+			if len(args) == 1 && args[0] != previous {
+				name = args[0]
+				if err := validateLLMName(ctx, catclient, name); err != nil {
+					return err
+				}
+				if _, err := catclient.LLM.Use(ctx, name); err != nil {
+					return err
+				}
+				fmt.Printf("switched from %s to %s\n", previous, name)
+			}
+
+			testCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+			defer cancel()
+
+			reply, err := catclient.LLM.Test(testCtx, cat.LLMTestOpts{
+				Message: cfg.message,
+				UserID:  cfg.userID,
+			})
+			if err != nil {
+				err = fmt.Errorf("testing '%s': %w", name, err)
+
+				if cfg.rollback && name != previous {
+					if _, rollbackErr := catclient.LLM.Use(ctx, previous); rollbackErr != nil {
+						return errors.Join(err, fmt.Errorf("rolling back to '%s': %w", previous, rollbackErr))
+					}
+					fmt.Printf("rolled back to %s\n", previous)
+				}
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintf(w, "LLM:\t%s\n", name)
+			fmt.Fprintf(w, "Time to first token:\t%s\n", reply.TimeToFirstToken.Round(time.Millisecond))
+			fmt.Fprintf(w, "Latency:\t%s\n", reply.Latency.Round(time.Millisecond))
+			if reply.Tokens > 0 {
+				fmt.Fprintf(w, "Streamed tokens:\t%d\n", reply.Tokens)
+			}
+			fmt.Fprintf(w, "Reply:\t%s\n", truncate(reply.Content, 80))
+			return w.Flush()
+		},
+	}
+
+	llmTestCmd.Flags().StringVar(&cfg.message, "message", cat.DefaultProbeMessage, "The probe message")
+	llmTestCmd.Flags().StringVar(&cfg.userID, "user-id", cat.ProbeUserID, "The user sending the probe message")
+	llmTestCmd.Flags().DurationVar(&cfg.timeout, "timeout", time.Minute, "The maximum time to wait for the reply")
+	llmTestCmd.Flags().BoolVar(&cfg.rollback, "rollback", false, "Select the previous LLM again if the test fails")
+
+	return llmTestCmd
 }
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/compare/*.tmpl
+var compareTemplates embed.FS
+
+// comparePrompt is a prompt of the prompts file, written as a string or as a map with a name and a text
+type comparePrompt struct {
+	Name string `yaml:"name"`
+	Text string `yaml:"text"`
+}
+
+func (p *comparePrompt) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Text)
+	}
+
+	type plain comparePrompt
+	return node.Decode((*plain)(p))
+}
+
+type compareReport struct {
+	CreatedAt time.Time             `json:"created_at"`
+	Configs   []string              `json:"configs"`
+	Summary   []compareConfigResult `json:"summary"`
+	Prompts   []comparePromptResult `json:"prompts"`
+}
+
+type compareConfigResult struct {
+	Config                string `json:"config"`
+	Answers               int    `json:"answers"`
+	Errors                int    `json:"errors"`
+	AvgLatencyMs          int64  `json:"avg_latency_ms"`
+	AvgTimeToFirstTokenMs int64  `json:"avg_time_to_first_token_ms"`
+	AvgTokens             int    `json:"avg_tokens"`
+}
+
+type comparePromptResult struct {
+	Name    string          `json:"name"`
+	Text    string          `json:"text"`
+	Answers []compareAnswer `json:"answers"`
+}
+
+type compareAnswer struct {
+	Config             string `json:"config"`
+	Answer             string `json:"answer,omitempty"`
+	Error              string `json:"error,omitempty"`
+	LatencyMs          int64  `json:"latency_ms"`
+	TimeToFirstTokenMs int64  `json:"time_to_first_token_ms"`
+	// Tokens approximates the tokens of the answer
+	Tokens int `json:"tokens"`
+}
+
+func NewLLMCompareCmd(catclient *cat.Client) *cobra.Command {
+	type compareCfg struct {
+		promptsFile string
+		configs     []string
+		format      string
+		output      string
+		userID      string
+		timeout     time.Duration
+	}
+
+	cfg := &compareCfg{}
+
+	llmCompareCmd := &cobra.Command{
+		Use:   "compare",
+		Short: "compare the answers of LLM configurations to the same prompts",
+		Long: `Run the prompts of a file through the chat with each configuration, selecting it with its stored settings,
+and write a report with the answers, the latencies and the approximated token counts.
+Each prompt is sent by its own user, named after --user-id, so that the answers don't share the chat history.
+The LLM selected before the comparison is restored at the end, and an interrupted comparison
+writes a partial report.
+
+The prompts file is a YAML list of prompts, as strings or with a name and a text:
+
+  prompts:
+    - What is the Cheshire Cat?
+    - name: summary
+      text: Summarize the plot of Alice in Wonderland in two sentences.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			if cfg.promptsFile == "" || len(cfg.configs) == 0 {
+				return fmt.Errorf("the prompts file and the configurations are required")
+			}
+
+			prompts, err := readComparePrompts(cfg.promptsFile)
+			if err != nil {
+				return err
+			}
+
+			var render func(io.Writer, *compareReport) error
+			switch cfg.format {
+			case "markdown":
+				render = renderMarkdownReport
+			case "html":
+				render = renderHTMLReport
+			case "json":
+				render = renderJSONReport
+			default:
+				return fmt.Errorf("unknown format '%s'", cfg.format)
+			}
+
+			for _, config := range cfg.configs {
+				if err := validateLLMName(ctx, catclient, config); err != nil {
+					return err
+				}
+			}
+
+			settings, err := catclient.LLM.GetAll(ctx)
+			if err != nil {
+				return err
+			}
+			original := settings.SelectedConfiguration
+
+			report, err := runComparison(ctx, catclient, prompts, cfg.configs, cfg.userID, cfg.timeout)
+
+			// the original LLM is restored even if the comparison was interrupted
+			if _, restoreErr := catclient.LLM.Use(context.Background(), original); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("restoring '%s': %w", original, restoreErr))
+			} else {
+				fmt.Fprintf(os.Stderr, "restored %s\n", original)
+			}
+
+			// an interrupted comparison still writes the answers collected so far
+			w := os.Stdout
+			if cfg.output != "" {
+				f, createErr := os.Create(cfg.output)
+				if createErr != nil {
+					return errors.Join(err, createErr)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if renderErr := render(w, report); renderErr != nil {
+				return errors.Join(err, renderErr)
+			}
+			if cfg.output != "" {
+				fmt.Fprintf(os.Stderr, "report written to %s\n", cfg.output)
+			}
+			if err != nil {
+				return fmt.Errorf("the report is partial: %w", err)
+			}
+			return nil
+		},
+	}
+
+	llmCompareCmd.Flags().StringVarP(&cfg.promptsFile, "file", "f", "", "The YAML file of the prompts")
+	llmCompareCmd.Flags().StringSliceVar(&cfg.configs, "configs", []string{}, "The LLM configurations to compare")
+	llmCompareCmd.Flags().StringVar(&cfg.format, "format", "markdown", "The format of the report (markdown, json, html)")
+	llmCompareCmd.Flags().StringVarP(&cfg.output, "output", "o", "", "The report file (default stdout)")
+	llmCompareCmd.Flags().StringVar(&cfg.userID, "user-id", cat.ProbeUserID, "The prefix of the users sending the prompts")
+	llmCompareCmd.Flags().DurationVar(&cfg.timeout, "timeout", 2*time.Minute, "The maximum time to wait for each answer")
+
+	_ = llmCompareCmd.RegisterFlagCompletionFunc("configs", completeLLMNames(catclient))
+
+	return llmCompareCmd
+}
+
+// runComparison selects each configuration in turn and sends all the prompts with it.
+// A configuration that can't be selected records the error as the answer of every prompt.
+// Each prompt is sent by a different user, so that the answers don't depend on the previous ones.
+// If the context is done the prompts not sent yet are recorded as interrupted, and the partial
+// report is returned along with the error of the context.
+func runComparison(ctx context.Context, catclient *cat.Client, prompts []comparePrompt, configs []string, userID string, timeout time.Duration) (*compareReport, error) {
+	report := &compareReport{
+		CreatedAt: time.Now(),
+		Configs:   configs,
+		Prompts:   make([]comparePromptResult, len(prompts)),
+	}
+	for i, prompt := range prompts {
+		report.Prompts[i] = comparePromptResult{Name: prompt.Name, Text: prompt.Text}
+	}
+
+	for c, config := range configs {
+		var useErr error
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "running %d prompts with %s\n", len(prompts), config)
+			_, useErr = catclient.LLM.Use(ctx, config)
+		}
+
+		for i, prompt := range prompts {
+			answer := compareAnswer{Config: config}
+
+			switch {
+			case ctx.Err() != nil:
+				answer.Error = "interrupted"
+
+			case useErr != nil:
+				answer.Error = fmt.Sprintf("selecting the configuration: %s", useErr)
+
+			default:
+				promptCtx, cancel := context.WithTimeout(ctx, timeout)
+				reply, err := catclient.Chat.Send(promptCtx, fmt.Sprintf("%s_%d_%d", userID, c+1, i+1), prompt.Text)
+				cancel()
+
+				switch {
+				case ctx.Err() != nil:
+					answer.Error = "interrupted"
+				case err != nil:
+					answer.Error = err.Error()
+				default:
+					answer.Answer = reply.Content
+					answer.LatencyMs = reply.Latency.Milliseconds()
+					answer.TimeToFirstTokenMs = reply.TimeToFirstToken.Milliseconds()
+					answer.Tokens = countTokens(reply.Content)
+				}
+			}
+
+			report.Prompts[i].Answers = append(report.Prompts[i].Answers, answer)
+		}
+	}
+
+	for i, config := range configs {
+		summary := compareConfigResult{Config: config}
+
+		var latency, ttft int64
+		tokens := 0
+		for _, prompt := range report.Prompts {
+			answer := prompt.Answers[i]
+			if answer.Error != "" {
+				summary.Errors++
+				continue
+			}
+			summary.Answers++
+			latency += answer.LatencyMs
+			ttft += answer.TimeToFirstTokenMs
+			tokens += answer.Tokens
+		}
+
+		if summary.Answers > 0 {
+			summary.AvgLatencyMs = latency / int64(summary.Answers)
+			summary.AvgTimeToFirstTokenMs = ttft / int64(summary.Answers)
+			summary.AvgTokens = tokens / summary.Answers
+		}
+		report.Summary = append(report.Summary, summary)
+	}
+
+	return report, ctx.Err()
+}
+
+func readComparePrompts(filename string) ([]comparePrompt, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file := struct {
+		Prompts []comparePrompt `yaml:"prompts"`
+	}{}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("invalid prompts file '%s': %w", filename, err)
+	}
+	if len(file.Prompts) == 0 {
+		return nil, fmt.Errorf("no prompts in '%s'", filename)
+	}
+
+	for i := range file.Prompts {
+		if strings.TrimSpace(file.Prompts[i].Text) == "" {
+			return nil, fmt.Errorf("empty text of prompt %d in '%s'", i+1, filename)
+		}
+		if file.Prompts[i].Name == "" {
+			file.Prompts[i].Name = truncate(file.Prompts[i].Text, 60)
+		}
+	}
+	return file.Prompts, nil
+}
+
+var compareTemplateFuncs = map[string]any{
+	"add": func(a, b int) int { return a + b },
+	"ms": func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).String()
+	},
+	"quote": func(s string) string {
+		return "> " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n> ")
+	},
+}
+
+func renderMarkdownReport(w io.Writer, report *compareReport) error {
+	tmpl, err := template.New("report.md.tmpl").Funcs(compareTemplateFuncs).ParseFS(compareTemplates, "templates/compare/report.md.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, report)
+}
+
+func renderHTMLReport(w io.Writer, report *compareReport) error {
+	tmpl, err := htmltemplate.New("report.html.tmpl").Funcs(compareTemplateFuncs).ParseFS(compareTemplates, "templates/compare/report.html.tmpl")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, report)
+}
+
+func renderJSONReport(w io.Writer, report *compareReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
+)
+
+// llmProfilesKey is the key of the profiles in the catctl config
+const llmProfilesKey = "llm_profiles"
+
+// envReference matches the values referencing an environment variable, like ${OPENAI_API_KEY}
+var envReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// llmProfile is a named LLM configuration stored client-side. The secret values can be
+// references to environment variables, resolved when the profile is applied.
+type llmProfile struct {
+	LLM   string         `yaml:"llm"`
+	Value map[string]any `yaml:"value"`
+}
+
+func NewLLMProfileCmd(catclient *cat.Client) *cobra.Command {
+	llmProfileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "manage named LLM profiles stored in the catctl config",
+		Long: `Manage named LLM profiles, stored in the catctl config file (.ccat.yml in the current directory).
+A profile is an LLM name and its settings, and the secret settings can reference environment variables
+with the ${NAME} syntax instead of being stored in plain text.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	llmProfileCmd.AddCommand(
+		NewLLMProfileApplyCmd(catclient),
+		NewLLMProfileDeleteCmd(),
+		NewLLMProfileListCmd(),
+		NewLLMProfileSaveCmd(catclient),
+	)
+
+	return llmProfileCmd
+}
+
+func NewLLMProfileSaveCmd(catclient *cat.Client) *cobra.Command {
+	type saveCfg struct {
+		llm            string
+		secretEnv      []string
+		allowPlaintext bool
+		force          bool
+	}
+
+	cfg := &saveCfg{}
+
+	llmProfileSaveCmd := &cobra.Command{
+		Use:   "save <name>",
+		Short: "save the settings of an LLM as a profile",
+		Long: `Save the settings stored in the Cat for an LLM (default the selected one) as a profile.
+
+The secret properties are stored as references to the environment variables named after them, like
+${OPENAI_API_KEY} for openai_api_key. Use --secret-env to reference a different variable, or
+--allow-plaintext to store their values in the config file.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			secretEnv, err := parseSetFlags(cfg.secretEnv)
+			if err != nil {
+				return err
+			}
+
+			profiles, configFile, err := readLLMProfiles()
+			if err != nil {
+				return err
+			}
+			if _, found := profiles[args[0]]; found && !cfg.force {
+				return fmt.Errorf("profile '%s' already exists (use --force to overwrite)", args[0])
+			}
+
+			if cfg.llm == "" {
+				settings, err := catclient.LLM.GetAll(ctx)
+				if err != nil {
+					return err
+				}
+				cfg.llm = settings.SelectedConfiguration
+			} else if err := validateLLMName(ctx, catclient, cfg.llm); err != nil {
+				return err
+			}
+
+			setting, err := catclient.LLM.GetByID(ctx, cfg.llm)
+			if err != nil {
+				return err
+			}
+
+			value := map[string]any{}
+			for key, v := range setting.Value {
+				prop := &cat.Schema{}
+				if setting.Schema != nil {
+					if p, found := setting.Schema.Properties[key]; found {
+						prop = &p
+					}
+				}
+
+				envVar, found := secretEnv[key]
+				if !found && !cfg.allowPlaintext && isSecret(prop, key) && v != nil && v != "" {
+					envVar, found = strings.ToUpper(key), true
+				}
+
+				if found {
+					value[key] = "${" + envVar + "}"
+					fmt.Fprintf(os.Stderr, "'%s' references the %s environment variable\n", key, envVar)
+					continue
+				}
+				value[key] = v
+				if isSecret(prop, key) && v != nil && v != "" {
+					fmt.Fprintf(os.Stderr, "warning: '%s' is stored in plain text\n", key)
+				}
+			}
+
+			for key := range secretEnv {
+				if _, found := setting.Value[key]; !found {
+					return fmt.Errorf("'%s' is not set in the settings of '%s'", key, cfg.llm)
+				}
+			}
+
+			profiles[args[0]] = &llmProfile{LLM: cfg.llm, Value: value}
+			if err := writeLLMProfiles(configFile, profiles); err != nil {
+				return err
+			}
+
+			fmt.Printf("profile %s saved with %s in %s\n", args[0], cfg.llm, configFile)
+			return nil
+		},
+	}
+
+	llmProfileSaveCmd.Flags().StringVar(&cfg.llm, "llm", "", "The LLM to save (default the selected one)")
+	llmProfileSaveCmd.Flags().StringArrayVar(&cfg.secretEnv, "secret-env", []string{}, "Reference an environment variable for a property (property=VARIABLE)")
+	llmProfileSaveCmd.Flags().BoolVar(&cfg.allowPlaintext, "allow-plaintext", false, "Store the values of the secret properties instead of referencing environment variables")
+	llmProfileSaveCmd.Flags().BoolVar(&cfg.force, "force", false, "Overwrite an existing profile")
+
+	_ = llmProfileSaveCmd.RegisterFlagCompletionFunc("llm", completeLLMNames(catclient))
+
+	return llmProfileSaveCmd
+}
+
+func NewLLMProfileListCmd() *cobra.Command {
+	llmProfileListCmd := &cobra.Command{
+		Use:           "list",
+		Short:         "list the LLM profiles",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, _, err := readLLMProfiles()
+			if err != nil {
+				return err
+			}
+
+			names := maps.Keys(profiles)
+			sort.Strings(names)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "NAME\tLLM\tENV")
+			for _, name := range names {
+				profile := profiles[name]
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, profile.LLM, strings.Join(profile.envVars(), ","))
+			}
+			return w.Flush()
+		},
+	}
+
+	return llmProfileListCmd
+}
+
+func NewLLMProfileApplyCmd(catclient *cat.Client) *cobra.Command {
+	llmProfileApplyCmd := &cobra.Command{
+		Use:               "apply <name>",
+		Short:             "apply an LLM profile to the Cat",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeLLMProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			profiles, _, err := readLLMProfiles()
+			if err != nil {
+				return err
+			}
+			profile, found := profiles[args[0]]
+			if !found {
+				return fmt.Errorf("profile '%s' not found", args[0])
+			}
+
+			value, err := profile.resolve()
+			if err != nil {
+				return fmt.Errorf("applying profile '%s': %w", args[0], err)
+			}
+
+			if _, err := catclient.LLM.Update(ctx, profile.LLM, value); err != nil {
+				return fmt.Errorf("applying profile '%s': %w", args[0], err)
+			}
+
+			fmt.Printf("profile %s applied: %s selected\n", args[0], profile.LLM)
+			return nil
+		},
+	}
+
+	return llmProfileApplyCmd
+}
+
+func NewLLMProfileDeleteCmd() *cobra.Command {
+	llmProfileDeleteCmd := &cobra.Command{
+		Use:               "delete <name>",
+		Short:             "delete an LLM profile",
+		SilenceUsage:      true,
+		SilenceErrors:     true,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeLLMProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, configFile, err := readLLMProfiles()
+			if err != nil {
+				return err
+			}
+			if _, found := profiles[args[0]]; !found {
+				return fmt.Errorf("profile '%s' not found", args[0])
+			}
+
+			delete(profiles, args[0])
+			if err := writeLLMProfiles(configFile, profiles); err != nil {
+				return err
+			}
+
+			fmt.Printf("profile %s deleted\n", args[0])
+			return nil
+		},
+	}
+
+	return llmProfileDeleteCmd
+}
+
+func completeLLMProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	profiles, _, err := readLLMProfiles()
+	if err != nil {
+		return []string{}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return maps.Keys(profiles), cobra.ShellCompDirectiveNoFileComp
+}
+
+// resolve returns the value of the profile, with the environment variables references replaced by their values
+func (p *llmProfile) resolve() (map[string]any, error) {
+	value := map[string]any{}
+	missing := []string{}
+
+	for key, v := range p.Value {
+		s, ok := v.(string)
+		if !ok {
+			value[key] = v
+			continue
+		}
+
+		match := envReference.FindStringSubmatch(s)
+		if match == nil {
+			value[key] = v
+			continue
+		}
+
+		envValue, found := os.LookupEnv(match[1])
+		if !found {
+			missing = append(missing, match[1])
+			continue
+		}
+		value[key] = envValue
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing environment variables: %s", strings.Join(missing, ", "))
+	}
+	return value, nil
+}
+
+// envVars returns the sorted names of the environment variables referenced by the profile
+func (p *llmProfile) envVars() []string {
+	vars := []string{}
+	for _, v := range p.Value {
+		if s, ok := v.(string); ok {
+			if match := envReference.FindStringSubmatch(s); match != nil {
+				vars = append(vars, match[1])
+			}
+		}
+	}
+	sort.Strings(vars)
+	return vars
+}
+
+// llmProfilesConfigFile returns the YAML config file of catctl in the current directory, existing or not
+func llmProfilesConfigFile() (string, error) {
+	for _, ext := range []string{".yml", ".yaml"} {
+		if _, err := os.Stat(defaultConfigFilename + ext); err == nil {
+			return defaultConfigFilename + ext, nil
+		}
+	}
+
+	for _, ext := range []string{".json", ".toml", ".hcl", ".env", ".properties", ".ini"} {
+		if _, err := os.Stat(defaultConfigFilename + ext); err == nil {
+			return "", fmt.Errorf("the profiles can be stored only in a YAML config, found '%s'", defaultConfigFilename+ext)
+		}
+	}
+
+	return defaultConfigFilename + ".yml", nil
+}
+
+// readLLMProfiles returns the profiles of the config, and the path of the config file
+func readLLMProfiles() (map[string]*llmProfile, string, error) {
+	configFile, err := llmProfilesConfigFile()
+	if err != nil {
+		return nil, "", err
+	}
+
+	profiles := map[string]*llmProfile{}
+
+	b, err := os.ReadFile(configFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return profiles, configFile, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	config := struct {
+		Profiles map[string]*llmProfile `yaml:"llm_profiles"`
+	}{}
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, "", fmt.Errorf("invalid config '%s': %w", configFile, err)
+	}
+	if config.Profiles != nil {
+		profiles = config.Profiles
+	}
+	return profiles, configFile, nil
+}
+
+// writeLLMProfiles replaces the profiles in the config file, keeping the other settings and their comments
+func writeLLMProfiles(configFile string, profiles map[string]*llmProfile) error {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+
+	b, err := os.ReadFile(configFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(b) > 0 {
+		if err := yaml.Unmarshal(b, doc); err != nil {
+			return fmt.Errorf("invalid config '%s': %w", configFile, err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config '%s': expected a map", configFile)
+	}
+
+	profilesNode := &yaml.Node{}
+	if err := profilesNode.Encode(profiles); err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == llmProfilesKey {
+			root.Content[i+1] = profilesNode
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: llmProfilesKey}, profilesNode)
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// the config can contain secrets, so it's made readable only by the user,
+	// also if it already existed with a wider mode
+	if err := os.WriteFile(configFile, out, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(configFile, 0o600)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	llmProvidersTTL     = time.Hour
+	llmProvidersTimeout = 3 * time.Second
+)
+
+// llmProvidersCache stores the names of the LLMs of a Cat, to complete them without a request every time
+type llmProvidersCache struct {
+	BaseURL   string    `json:"base_url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Names     []string  `json:"names"`
+}
+
+// llmProviders returns the names of the LLMs supported by the Cat, including the ones added by plugins.
+// The names are cached locally for llmProvidersTTL, unless refresh is set. If the server is unreachable
+// the cached names are used even if expired, then the static ValidLLMs. The bool reports if the names come
+// from the server, now or from a cache not expired yet.
+func llmProviders(ctx context.Context, catclient *cat.Client, refresh bool) ([]string, bool) {
+	cacheFile := llmProvidersCacheFile(catclient.BaseURL)
+	cache := readLLMProvidersCache(cacheFile, catclient.BaseURL)
+
+	if cache != nil && !refresh && time.Since(cache.FetchedAt) < llmProvidersTTL {
+		return cache.Names, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, llmProvidersTimeout)
+	defer cancel()
+
+	settings, err := catclient.LLM.Get(ctx)
+	if err != nil {
+		if cache != nil {
+			return cache.Names, false
+		}
+		return ValidLLMs, false
+	}
+
+	names := []string{}
+	for _, setting := range settings {
+		names = append(names, setting.Name)
+	}
+
+	if cacheFile != "" {
+		writeLLMProvidersCache(cacheFile, &llmProvidersCache{
+			BaseURL:   catclient.BaseURL,
+			FetchedAt: time.Now(),
+			Names:     names,
+		})
+	}
+
+	return names, true
+}
+
+// validateLLMName checks that the Cat supports the LLM, refreshing the cached names if it's not found.
+// The name is not checked if the server is unreachable.
+func validateLLMName(ctx context.Context, catclient *cat.Client, name string) error {
+	names, fromServer := llmProviders(ctx, catclient, false)
+	if slices.Contains(names, name) {
+		return nil
+	}
+
+	// the LLM could have been added by a plugin after the names were cached
+	names, fromServer = llmProviders(ctx, catclient, true)
+	if !fromServer || slices.Contains(names, name) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown LLM '%s' (available: %s)", name, strings.Join(names, ", "))
+}
+
+// completeLLMNames completes the first argument with the names of the LLMs
+func completeLLMNames(catclient *cat.Client) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return []string{}, cobra.ShellCompDirectiveNoFileComp
+		}
+		if err := initClient(cmd); err != nil {
+			return ValidLLMs, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		names, _ := llmProviders(context.Background(), catclient, false)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// withLLMNamesHelp appends the names of the LLMs to the help of the command
+func withLLMNamesHelp(cmd *cobra.Command, catclient *cat.Client) {
+	long := cmd.Long
+	if long == "" {
+		long = cmd.Short
+	}
+
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		c.Long = long
+		if err := initClient(c); err == nil {
+			// the help doesn't wait for the server: only the cached names are shown
+			names, source := ValidLLMs, "known LLMs"
+			if cache := readLLMProvidersCache(llmProvidersCacheFile(catclient.BaseURL), catclient.BaseURL); cache != nil {
+				names, source = cache.Names, "available LLMs (cached "+cache.FetchedAt.Format(time.DateTime)+")"
+			}
+			c.Long += "\n\nThe " + source + ":\n  " + strings.Join(names, "\n  ")
+		}
+
+		// the default help of the root, as the parents could have a custom one calling back this
+		c.Root().HelpFunc()(c, args)
+	})
+}
+
+func llmProvidersCacheFile(baseURL string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(baseURL))
+	return filepath.Join(dir, "catctl", "llm-providers-"+hex.EncodeToString(hash[:8])+".json")
+}
+
+func readLLMProvidersCache(filename, baseURL string) *llmProvidersCache {
+	if filename == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	cache := &llmProvidersCache{}
+	if err := json.Unmarshal(b, cache); err != nil || cache.BaseURL != baseURL || len(cache.Names) == 0 {
+		return nil
+	}
+	return cache
+}
+
+// writeLLMProvidersCache writes the cache, ignoring the errors: the names are fetched again on the next run
+func writeLLMProvidersCache(filename string, cache *llmProvidersCache) {
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(filename, b, 0o644)
+}
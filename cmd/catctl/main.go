@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
 	cat "github.com/enrichman/ccat-client-go"
@@ -27,7 +29,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err := rootCmd.Execute(); err != nil {
+	// the first interrupt cancels the context of the command, letting it stop cleanly, the second one terminates it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -71,9 +80,18 @@ func NewRootCmd() (*cobra.Command, error) {
 		return nil, err
 	}
 
+	embedderCmd, err := NewEmbedderCmd(catclient)
+	if err != nil {
+		return nil, err
+	}
+
 	rootCmd.AddCommand(
 		NewChatCmd(catclient),
+		embedderCmd,
+		NewIngestCmd(catclient),
 		llmCmd,
+		NewMemoryCmd(catclient),
+		NewPluginsCmd(catclient),
 		NewSettingsCmd(catclient),
 		NewVersionCmd(catclient),
 	)
@@ -84,6 +102,15 @@ func NewRootCmd() (*cobra.Command, error) {
 	return rootCmd, nil
 }
 
+// initClient initializes the client outside of the run of a command, as in completions and help
+func initClient(cmd *cobra.Command) error {
+	root := cmd.Root()
+	if root.PersistentPreRunE == nil {
+		return nil
+	}
+	return root.PersistentPreRunE(cmd, []string{})
+}
+
 func initializeConfig(cmd *cobra.Command) error {
 	v := viper.New()
 
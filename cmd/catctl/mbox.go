@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mboxMessage is a parsed email, with its text body
+type mboxMessage struct {
+	ID         string
+	InReplyTo  string
+	References []string
+	Subject    string
+	From       *mail.Address
+	To         []*mail.Address
+	Cc         []*mail.Address
+	Date       time.Time
+	Body       string
+}
+
+// mboxThread is a conversation, with its messages sorted by date
+type mboxThread struct {
+	ID       string
+	Subject  string
+	Messages []*mboxMessage
+}
+
+var (
+	escapedFromLine = regexp.MustCompile(`^>+From `)
+	subjectPrefix   = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|r)\s*(\[\d+\])?\s*:\s*)+`)
+	htmlTag         = regexp.MustCompile(`(?s)<[^>]*>`)
+	wordDecoder     = new(mime.WordDecoder)
+)
+
+// readMbox splits an mbox archive in messages. A message starts with a "From " line preceded by an empty line,
+// and the escaped ">From " lines of the body are restored (mboxrd).
+// The malformed messages are skipped, and returned as errors along with the parsed ones.
+func readMbox(r io.Reader) ([]*mboxMessage, []error, error) {
+	messages := []*mboxMessage{}
+	skipped := []error{}
+
+	var current *bytes.Buffer
+	prevEmpty := true
+	lineNo, startLine := 0, 0
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		msg, err := parseMessage(current.Bytes())
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("message at line %d: %w", startLine, err))
+			return
+		}
+		messages = append(messages, msg)
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			lineNo++
+
+			switch {
+			case prevEmpty && strings.HasPrefix(line, "From "):
+				flush()
+				current = new(bytes.Buffer)
+				startLine = lineNo
+
+			case current != nil:
+				if escapedFromLine.MatchString(line) {
+					line = line[1:]
+				}
+				current.WriteString(line)
+			}
+			prevEmpty = strings.TrimRight(line, "\r\n") == ""
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	flush()
+	return messages, skipped, nil
+}
+
+func parseMessage(raw []byte) (*mboxMessage, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	msg := &mboxMessage{
+		ID:         trimMessageID(m.Header.Get("Message-Id")),
+		InReplyTo:  trimMessageID(m.Header.Get("In-Reply-To")),
+		References: strings.Fields(m.Header.Get("References")),
+		Subject:    decodeHeader(m.Header.Get("Subject")),
+	}
+
+	for i, ref := range msg.References {
+		msg.References[i] = trimMessageID(ref)
+	}
+
+	if from, err := mail.ParseAddress(m.Header.Get("From")); err == nil {
+		msg.From = from
+	} else {
+		msg.From = &mail.Address{Address: decodeHeader(m.Header.Get("From"))}
+	}
+	msg.To, _ = m.Header.AddressList("To")
+	msg.Cc, _ = m.Header.AddressList("Cc")
+	msg.Date, _ = m.Header.Date()
+
+	body, err := readBody(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading body of '%s': %w", msg.ID, err)
+	}
+	msg.Body = strings.TrimSpace(body)
+
+	return msg, nil
+}
+
+// readBody returns the text of the body, preferring the text/plain parts of multipart messages over the HTML ones
+func readBody(contentType, encoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, newlineStripper{r})
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+
+		var plain, html string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			text, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+
+			switch {
+			case partType == "text/html" && html == "":
+				html = text
+			case plain == "" && text != "":
+				plain = text
+			}
+		}
+
+		if plain != "" {
+			return plain, nil
+		}
+		return html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		// attachments are not ingested
+		return "", nil
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	if mediaType == "text/html" {
+		return htmlTag.ReplaceAllString(string(b), ""), nil
+	}
+	return string(b), nil
+}
+
+// newlineStripper drops the line breaks of base64 encoded bodies
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	j := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[j] = b
+			j++
+		}
+	}
+	return j, err
+}
+
+func decodeHeader(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+func formatAddress(address *mail.Address) string {
+	if address.Name == "" {
+		return address.Address
+	}
+	return address.Name + " <" + address.Address + ">"
+}
+
+func trimMessageID(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
+
+func normalizeSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subjectPrefix.ReplaceAllString(subject, "")))
+}
+
+// groupThreads groups the messages in conversations. A message belongs to the thread of the first of its references,
+// or of the message it replies to. Messages without references are grouped by their normalized subject.
+func groupThreads(messages []*mboxMessage) []*mboxThread {
+	threadByKey := map[string]*mboxThread{}
+	threads := []*mboxThread{}
+
+	// maps each message to its thread, to follow replies to messages that are replies
+	rootByID := map[string]string{}
+	rootBySubject := map[string]string{}
+
+	for _, msg := range messages {
+		var root string
+		switch {
+		case len(msg.References) > 0:
+			root = msg.References[0]
+		case msg.InReplyTo != "":
+			root = msg.InReplyTo
+			if r, found := rootByID[root]; found {
+				root = r
+			}
+		default:
+			subject := normalizeSubject(msg.Subject)
+			if r, found := rootBySubject[subject]; found && subject != "" {
+				root = r
+			} else {
+				root = msg.ID
+				rootBySubject[subject] = root
+			}
+		}
+		if root == "" {
+			root = fmt.Sprintf("%s-%d", msg.Date.Format(time.RFC3339), len(rootByID))
+		}
+		rootByID[msg.ID] = root
+
+		thread, found := threadByKey[root]
+		if !found {
+			thread = &mboxThread{ID: root, Subject: strings.TrimSpace(subjectPrefix.ReplaceAllString(msg.Subject, ""))}
+			threadByKey[root] = thread
+			threads = append(threads, thread)
+		}
+		thread.Messages = append(thread.Messages, msg)
+	}
+
+	for _, thread := range threads {
+		sort.SliceStable(thread.Messages, func(i, j int) bool {
+			return thread.Messages[i].Date.Before(thread.Messages[j].Date)
+		})
+	}
+
+	return threads
+}
+
+// participants returns the addresses of the senders of the thread, in order of appearance
+func (t *mboxThread) participants() []string {
+	seen := map[string]bool{}
+	participants := []string{}
+	for _, msg := range t.Messages {
+		if !seen[msg.From.Address] {
+			seen[msg.From.Address] = true
+			participants = append(participants, formatAddress(msg.From))
+		}
+	}
+	return participants
+}
+
+// document converts the thread to the text ingested by the Cat
+func (t *mboxThread) document() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Subject: %s\n", t.Subject)
+	fmt.Fprintf(&sb, "Participants: %s\n", strings.Join(t.participants(), ", "))
+
+	for _, msg := range t.Messages {
+		fmt.Fprintf(&sb, "\n---\n\nFrom: %s\nDate: %s\nSubject: %s\n\n%s\n",
+			formatAddress(msg.From), msg.Date.Format(time.RFC1123Z), msg.Subject, msg.Body,
+		)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/maps"
+)
+
+func NewMemoryCmd(catclient *cat.Client) *cobra.Command {
+	memoryCmd := &cobra.Command{
+		Use:   "memory",
+		Short: "manage the vector memory",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	memoryCmd.AddCommand(
+		NewMemoryCollectionsCmd(catclient),
+		NewMemoryRecallCmd(catclient),
+		NewMemoryWipeCmd(catclient),
+		NewMemoryPointsCmd(catclient),
+		NewMemoryExportCmd(catclient),
+		NewMemoryImportCmd(catclient),
+	)
+
+	return memoryCmd
+}
+
+func NewMemoryCollectionsCmd(catclient *cat.Client) *cobra.Command {
+	memoryCollectionsCmd := &cobra.Command{
+		Use:           "collections",
+		Short:         "list the memory collections",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			collections, err := catclient.Memory.Collections(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVECTORS")
+			for _, collection := range collections {
+				fmt.Fprintf(w, "%s\t%d\n", collection.Name, collection.VectorsCount)
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	return memoryCollectionsCmd
+}
+
+func NewMemoryRecallCmd(catclient *cat.Client) *cobra.Command {
+	type recallCfg struct {
+		k int
+	}
+
+	cfg := &recallCfg{}
+
+	memoryRecallCmd := &cobra.Command{
+		Use:           "recall <text>",
+		Short:         "recall the most similar memories to a text",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			result, err := catclient.Memory.Recall(cmd.Context(), args[0], cfg.k)
+			if err != nil {
+				return err
+			}
+
+			collections := maps.Keys(result.Collections)
+			sort.Strings(collections)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "COLLECTION\tSCORE\tID\tSOURCE\tCONTENT")
+			for _, collection := range collections {
+				for _, point := range result.Collections[collection] {
+					fmt.Fprintf(w, "%s\t%.3f\t%s\t%v\t%s\n",
+						collection, point.Score, point.ID, pointSource(point), truncate(point.PageContent, 60),
+					)
+				}
+			}
+			w.Flush()
+
+			return nil
+		},
+	}
+
+	memoryRecallCmd.Flags().IntVarP(&cfg.k, "top-k", "k", 5, "The number of memories to recall for each collection")
+
+	return memoryRecallCmd
+}
+
+func NewMemoryWipeCmd(catclient *cat.Client) *cobra.Command {
+	type wipeCfg struct {
+		yes bool
+	}
+
+	cfg := &wipeCfg{}
+
+	memoryWipeCmd := &cobra.Command{
+		Use:           "wipe [collection]",
+		Short:         "delete all the memories of a collection, or of every collection",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return collectionNames(cmd, catclient), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := "every collection"
+			if len(args) == 1 {
+				target = fmt.Sprintf("the '%s' collection", args[0])
+			}
+
+			if !cfg.yes {
+				ok, err := confirm("All the memories of %s will be deleted.", target)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Println("aborted")
+					return nil
+				}
+			}
+
+			if len(args) == 1 {
+				return catclient.Memory.WipeCollection(cmd.Context(), args[0])
+			}
+			return catclient.Memory.WipeAll(cmd.Context())
+		},
+	}
+
+	memoryWipeCmd.Flags().BoolVarP(&cfg.yes, "yes", "y", false, "Skip the confirmation")
+
+	return memoryWipeCmd
+}
+
+func NewMemoryPointsCmd(catclient *cat.Client) *cobra.Command {
+	memoryPointsCmd := &cobra.Command{
+		Use:   "points",
+		Short: "manage the points of a memory collection",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	memoryPointsCmd.AddCommand(
+		NewMemoryPointsGetCmd(catclient),
+		NewMemoryPointsCreateCmd(catclient),
+		NewMemoryPointsDeleteCmd(catclient),
+	)
+
+	return memoryPointsCmd
+}
+
+func NewMemoryPointsGetCmd(catclient *cat.Client) *cobra.Command {
+	type getCfg struct {
+		limit  int
+		output string
+	}
+
+	cfg := &getCfg{}
+
+	memoryPointsGetCmd := &cobra.Command{
+		Use:           "get <collection> [id...]",
+		Short:         "get the points of a collection",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return collectionNames(cmd, catclient), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			collection, ids := args[0], mapset.NewSet(args[1:]...)
+
+			points := []*cat.MemoryPoint{}
+
+			if ids.Cardinality() == 0 {
+				page, err := catclient.Memory.Points(cmd.Context(), collection, cat.PointsGetOpts{Limit: cfg.limit})
+				if err != nil {
+					return err
+				}
+				points = page.Points
+			} else {
+				// there is no endpoint to get a single point, so we need to look for them
+				missing := ids.Clone()
+				err := catclient.Memory.AllPoints(cmd.Context(), collection, 100, func(page []*cat.MemoryPoint) error {
+					for _, point := range page {
+						if missing.Contains(point.ID) {
+							points = append(points, point)
+							missing.Remove(point.ID)
+						}
+					}
+					if missing.Cardinality() == 0 {
+						return cat.ErrStopPaging
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+
+				if missing.Cardinality() > 0 {
+					// the found points are printed anyway
+					notFound := missing.ToSlice()
+					sort.Strings(notFound)
+					return errors.Join(
+						printPoints(cfg.output, points),
+						fmt.Errorf("points not found in '%s': %s", collection, strings.Join(notFound, ", ")),
+					)
+				}
+			}
+
+			return printPoints(cfg.output, points)
+		},
+	}
+
+	memoryPointsGetCmd.Flags().IntVar(&cfg.limit, "limit", 100, "The max number of points to get")
+	memoryPointsGetCmd.Flags().StringVarP(&cfg.output, "output", "o", "table", "The output format (table, json)")
+
+	return memoryPointsGetCmd
+}
+
+func NewMemoryPointsCreateCmd(catclient *cat.Client) *cobra.Command {
+	type createCfg struct {
+		metadata []string
+		output   string
+	}
+
+	cfg := &createCfg{}
+
+	memoryPointsCreateCmd := &cobra.Command{
+		Use:           "create <collection> <content>",
+		Short:         "create a point in a collection",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return collectionNames(cmd, catclient), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			metadata, err := parseKeyValues(cfg.metadata)
+			if err != nil {
+				return err
+			}
+
+			point, err := catclient.Memory.CreatePoint(cmd.Context(), args[0], cat.MemoryPointCreateRequest{
+				Content:  args[1],
+				Metadata: metadata,
+			})
+			if err != nil {
+				return fmt.Errorf("creating point: %w", err)
+			}
+
+			return printPoints(cfg.output, []*cat.MemoryPoint{point})
+		},
+	}
+
+	memoryPointsCreateCmd.Flags().StringArrayVar(&cfg.metadata, "metadata", []string{}, "A metadata of the point (key=value)")
+	memoryPointsCreateCmd.Flags().StringVarP(&cfg.output, "output", "o", "table", "The output format (table, json)")
+
+	return memoryPointsCreateCmd
+}
+
+func NewMemoryPointsDeleteCmd(catclient *cat.Client) *cobra.Command {
+	type deleteCfg struct {
+		filter []string
+		yes    bool
+	}
+
+	cfg := &deleteCfg{}
+
+	memoryPointsDeleteCmd := &cobra.Command{
+		Use:           "delete <collection> [id...]",
+		Short:         "delete points by id, or all the points matching a metadata filter",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return collectionNames(cmd, catclient), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			collection, ids := args[0], args[1:]
+
+			if len(ids) > 0 && len(cfg.filter) > 0 {
+				return fmt.Errorf("points can be deleted by id or by --filter, not both")
+			}
+
+			if len(ids) == 0 {
+				if len(cfg.filter) == 0 {
+					return fmt.Errorf("missing points to delete: specify their ids or a --filter")
+				}
+
+				filter, err := parseKeyValues(cfg.filter)
+				if err != nil {
+					return err
+				}
+
+				if !cfg.yes {
+					ok, err := confirm("All the points of '%s' matching %v will be deleted.", collection, filter)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						fmt.Println("aborted")
+						return nil
+					}
+				}
+
+				return catclient.Memory.DeletePointsByMetadata(cmd.Context(), collection, filter)
+			}
+
+			for _, id := range ids {
+				if err := catclient.Memory.DeletePoint(cmd.Context(), collection, id); err != nil {
+					return fmt.Errorf("deleting point '%s': %w", id, err)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	memoryPointsDeleteCmd.Flags().StringArrayVar(&cfg.filter, "filter", []string{}, "A metadata filter (key=value)")
+	memoryPointsDeleteCmd.Flags().BoolVarP(&cfg.yes, "yes", "y", false, "Skip the confirmation")
+
+	return memoryPointsDeleteCmd
+}
+
+func printPoints(output string, points []*cat.MemoryPoint) error {
+	switch output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(points)
+
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+		fmt.Fprintln(w, "ID\tSOURCE\tCONTENT")
+		for _, point := range points {
+			fmt.Fprintf(w, "%s\t%v\t%s\n", point.ID, pointSource(point), truncate(point.PageContent, 60))
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+}
+
+// parseKeyValues parses a list of key=value pairs
+func parseKeyValues(keyValues []string) (map[string]any, error) {
+	m := map[string]any{}
+	for _, keyValue := range keyValues {
+		key, value, found := strings.Cut(keyValue, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid key value '%s': expected key=value", keyValue)
+		}
+		m[key] = value
+	}
+	return m, nil
+}
+
+func collectionNames(cmd *cobra.Command, catclient *cat.Client) []string {
+	collections, err := catclient.Memory.Collections(cmd.Context())
+	if err != nil {
+		return []string{}
+	}
+
+	names := []string{}
+	for _, collection := range collections {
+		names = append(names, collection.Name)
+	}
+	return names
+}
+
+func pointSource(point *cat.MemoryPoint) any {
+	if source, found := point.Metadata["source"]; found {
+		return source
+	}
+	return "-"
+}
+
+// truncate shortens a text to a single line of at most n runes
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	memoryArchiveFormatVersion = 1
+	memoryManifestFilename     = "manifest.json"
+)
+
+// memoryManifest is the first entry of a memory archive, followed by a JSONL file of points for each collection
+type memoryManifest struct {
+	FormatVersion int              `json:"format_version"`
+	CreatedAt     time.Time        `json:"created_at"`
+	CatVersion    string           `json:"cat_version"`
+	Embedder      cat.EmbedderInfo `json:"embedder"`
+	WithVectors   bool             `json:"with_vectors"`
+	Collections   map[string]int   `json:"collections"`
+}
+
+type memoryExportOpts struct {
+	collections []string
+	withVectors bool
+	pageSize    int
+}
+
+type memoryImportOpts struct {
+	collections []string
+	reembed     bool
+}
+
+func NewMemoryExportCmd(catclient *cat.Client) *cobra.Command {
+	type exportCfg struct {
+		output string
+		memoryExportOpts
+	}
+
+	cfg := &exportCfg{}
+
+	memoryExportCmd := &cobra.Command{
+		Use:           "export",
+		Short:         "export the points of the memory collections into a compressed archive",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfg.output == "" {
+				cfg.output = fmt.Sprintf("memory-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+
+			f, err := os.Create(cfg.output)
+			if err != nil {
+				return err
+			}
+
+			manifest, err := exportMemory(cmd.Context(), catclient, f, cfg.memoryExportOpts)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(cfg.output)
+				return fmt.Errorf("exporting memory: %w", err)
+			}
+
+			for collection, count := range manifest.Collections {
+				fmt.Printf("%s: %d points\n", collection, count)
+			}
+			fmt.Println("memory exported to", cfg.output)
+
+			return nil
+		},
+	}
+
+	memoryExportCmd.Flags().StringVarP(&cfg.output, "output", "o", "", "The archive file (default memory-<timestamp>.tar.gz)")
+	memoryExportCmd.Flags().StringSliceVar(&cfg.collections, "collections", []string{}, "The collections to export (default all)")
+	memoryExportCmd.Flags().BoolVar(&cfg.withVectors, "with-vectors", false, "Export the vectors of the points")
+	memoryExportCmd.Flags().IntVar(&cfg.pageSize, "page-size", 100, "The number of points fetched for each request")
+
+	return memoryExportCmd
+}
+
+func NewMemoryImportCmd(catclient *cat.Client) *cobra.Command {
+	cfg := &memoryImportOpts{}
+
+	memoryImportCmd := &cobra.Command{
+		Use:   "import <archive>",
+		Short: "import the points of a memory archive",
+		Long: `Import the points of a memory archive created with 'memory export'.
+
+The vectors of an archive exported with --with-vectors are restored when the Cat
+uses the same embedder, otherwise the archive is refused unless --reembed is used
+to embed again the content. Only the declarative vectors can be restored: the points
+of the other collections are always embedded again.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			imported, err := importMemory(cmd.Context(), catclient, f, *cfg)
+			for collection, count := range imported {
+				fmt.Printf("%s: %d points imported\n", collection, count)
+			}
+			return err
+		},
+	}
+
+	memoryImportCmd.Flags().StringSliceVar(&cfg.collections, "collections", []string{"declarative", "episodic"}, "The collections to import")
+	memoryImportCmd.Flags().BoolVar(&cfg.reembed, "reembed", false, "Embed again the content of the points instead of restoring the archived vectors")
+
+	return memoryImportCmd
+}
+
+// exportMemory writes a gzipped tar archive with the manifest and the points of the collections.
+// The points are first paged into temporary files, because the tar headers need the size of each file.
+func exportMemory(ctx context.Context, catclient *cat.Client, w io.Writer, opts memoryExportOpts) (*memoryManifest, error) {
+	version, err := catclient.Server.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, err := catclient.Memory.Embedder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collections := opts.collections
+	if len(collections) == 0 {
+		all, err := catclient.Memory.Collections(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, collection := range all {
+			collections = append(collections, collection.Name)
+		}
+	}
+
+	manifest := &memoryManifest{
+		FormatVersion: memoryArchiveFormatVersion,
+		CreatedAt:     time.Now().UTC(),
+		CatVersion:    version.Version,
+		Embedder:      *embedder,
+		WithVectors:   opts.withVectors,
+		Collections:   map[string]int{},
+	}
+
+	tmpFiles := map[string]*os.File{}
+	defer func() {
+		for _, f := range tmpFiles {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	for _, collection := range collections {
+		f, err := os.CreateTemp("", "catctl-memory-*.jsonl")
+		if err != nil {
+			return nil, err
+		}
+		tmpFiles[collection] = f
+
+		bw := bufio.NewWriter(f)
+		enc := json.NewEncoder(bw)
+
+		err = catclient.Memory.AllPoints(ctx, collection, opts.pageSize, func(points []*cat.MemoryPoint) error {
+			for _, point := range points {
+				if !opts.withVectors {
+					point.Vector = nil
+				}
+				if err := enc.Encode(point); err != nil {
+					return err
+				}
+				manifest.Collections[collection]++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("exporting collection '%s': %w", collection, err)
+		}
+
+		if err := bw.Flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeTarEntry(tw, memoryManifestFilename, bytes.NewReader(manifestBytes), int64(len(manifestBytes))); err != nil {
+		return nil, err
+	}
+
+	for _, collection := range collections {
+		f := tmpFiles[collection]
+
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if err := writeTarEntry(tw, collection+".jsonl", f, info.Size()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return manifest, gw.Close()
+}
+
+// importMemory restores the points of a memory archive, returning the number of imported points for each collection.
+// Declarative points with vectors created by the same embedder are uploaded as they are through the rabbit hole,
+// while the other points are created with the memory API, that embeds their content with the current embedder.
+func importMemory(ctx context.Context, catclient *cat.Client, r io.Reader, opts memoryImportOpts) (map[string]int, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory archive: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	manifest, err := readMemoryManifest(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	embedder, err := catclient.Memory.Embedder(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.WithVectors && manifest.Embedder != *embedder && !opts.reembed {
+		return nil, fmt.Errorf(
+			"the archive vectors were created by %s (%d dimensions) but the Cat embedder is %s (%d): use --reembed to embed again the content",
+			manifest.Embedder.Name, manifest.Embedder.Size, embedder.Name, embedder.Size,
+		)
+	}
+
+	// the vectors are restored only if they were created by the same embedder of the Cat
+	keepVectors := manifest.WithVectors && manifest.Embedder == *embedder && !opts.reembed
+
+	toImport := mapset.NewSet(opts.collections...)
+	imported := map[string]int{}
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return imported, nil
+		}
+		if err != nil {
+			return imported, err
+		}
+
+		collection := strings.TrimSuffix(path.Base(header.Name), ".jsonl")
+		if toImport.Cardinality() > 0 && !toImport.Contains(collection) {
+			continue
+		}
+
+		dec := json.NewDecoder(tr)
+
+		if keepVectors && collection == "declarative" {
+			uploaded, err := uploadDeclarativePoints(ctx, catclient, manifest.Embedder, dec)
+			imported[collection] += uploaded
+			if err != nil {
+				return imported, fmt.Errorf("importing declarative points: %w", err)
+			}
+			continue
+		}
+
+		for dec.More() {
+			point := &cat.MemoryPoint{}
+			if err := dec.Decode(point); err != nil {
+				return imported, fmt.Errorf("invalid point in '%s': %w", header.Name, err)
+			}
+
+			_, err := catclient.Memory.CreatePoint(ctx, collection, cat.MemoryPointCreateRequest{
+				Content:  point.PageContent,
+				Metadata: point.Metadata,
+			})
+			if err != nil {
+				return imported, fmt.Errorf("importing point '%s' in '%s': %w", point.ID, collection, err)
+			}
+			imported[collection]++
+		}
+	}
+}
+
+// uploadDeclarativePoints uploads the decoded points with their vectors, streaming them
+// in a single memory file without holding the whole collection in memory
+func uploadDeclarativePoints(ctx context.Context, catclient *cat.Client, embedder cat.EmbedderInfo, dec *json.Decoder) (int, error) {
+	if !dec.More() {
+		return 0, nil
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	count := 0
+	go func() {
+		pw.CloseWithError(writeMemoryFile(pw, embedder, dec, &count))
+	}()
+
+	if _, err := catclient.RabbitHole.UploadMemory(ctx, pr); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// writeMemoryFile writes the JSON of a MemoryFile with the decoded points, validating each of them
+func writeMemoryFile(w io.Writer, embedder cat.EmbedderInfo, dec *json.Decoder, count *int) error {
+	b, err := json.Marshal(embedder)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, `{"embedder":%s,"collections":{"declarative":[`, b); err != nil {
+		return err
+	}
+
+	for i := 0; dec.More(); i++ {
+		point := &cat.MemoryPoint{}
+		if err := dec.Decode(point); err != nil {
+			return fmt.Errorf("invalid point: %w", err)
+		}
+		if err := point.ValidateDeclarative(embedder.Size); err != nil {
+			return fmt.Errorf("declarative memory %d: %w", i, err)
+		}
+
+		b, err := json.Marshal(point)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b = append([]byte(","), b...)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		*count = i + 1
+	}
+
+	_, err = io.WriteString(w, "]}}")
+	return err
+}
+
+func readMemoryManifest(tr *tar.Reader) (*memoryManifest, error) {
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory archive: %w", err)
+	}
+	if header.Name != memoryManifestFilename {
+		return nil, fmt.Errorf("invalid memory archive: %s is not the first entry", memoryManifestFilename)
+	}
+
+	manifest := &memoryManifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid memory manifest: %w", err)
+	}
+	if manifest.FormatVersion != memoryArchiveFormatVersion {
+		return nil, fmt.Errorf("unsupported memory archive version %d", manifest.FormatVersion)
+	}
+
+	return manifest, nil
+}
+
+func writeTarEntry(tw *tar.Writer, name string, r io.Reader, size int64) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, r)
+	return err
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	cat "github.com/enrichman/ccat-client-go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed templates/plugin/*.tmpl
+var pluginTemplates embed.FS
+
+func NewPluginsCmd(catclient *cat.Client) *cobra.Command {
+	pluginsCmd := &cobra.Command{
+		Use:   "plugins",
+		Short: "manage plugins",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	pluginsCmd.AddCommand(
+		NewPluginsConfigureCmd(catclient),
+		NewPluginsInitCmd(),
+		NewPluginsSyncCmd(catclient),
+	)
+
+	return pluginsCmd
+}
+
+func NewPluginsSyncCmd(catclient *cat.Client) *cobra.Command {
+	type syncCfg struct {
+		filename string
+		dryRun   bool
+	}
+
+	cfg := &syncCfg{}
+
+	pluginsSyncCmd := &cobra.Command{
+		Use:           "sync",
+		Short:         "install, upgrade, remove and toggle plugins to match the ones listed in a file",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cfg.filename == "" {
+				return fmt.Errorf("missing plugins file (use -f)")
+			}
+
+			desired, err := readPluginsFile(cfg.filename)
+			if err != nil {
+				return err
+			}
+
+			plan, err := catclient.Plugins.Plan(cmd.Context(), desired)
+			if err != nil {
+				return fmt.Errorf("planning plugins sync: %w", err)
+			}
+
+			if len(plan) == 0 {
+				fmt.Println("plugins are already in sync")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+			fmt.Fprintln(w, "ACTION\tPLUGIN\tFROM\tTO")
+			for _, action := range plan {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", action.Type, action.PluginID, action.FromVersion, action.ToVersion)
+			}
+			w.Flush()
+
+			if cfg.dryRun {
+				return nil
+			}
+
+			// the printed plan is the one applied, then the result is checked against the file
+			applied, err := catclient.Plugins.Apply(cmd.Context(), plan)
+			fmt.Printf("\n%d/%d actions applied\n", len(applied), len(plan))
+			if err != nil {
+				return err
+			}
+			return catclient.Plugins.Verify(cmd.Context(), desired)
+		},
+	}
+
+	pluginsSyncCmd.Flags().StringVarP(&cfg.filename, "filename", "f", "", "The YAML file listing the desired plugins")
+	pluginsSyncCmd.Flags().BoolVar(&cfg.dryRun, "dry-run", false, "Only print the plan, without applying it")
+
+	return pluginsSyncCmd
+}
+
+// readPluginsFile reads the desired plugins. Relative paths of local archives are resolved from the file location.
+func readPluginsFile(filename string) ([]cat.PluginSpec, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginsFile := struct {
+		Plugins []cat.PluginSpec `yaml:"plugins"`
+	}{}
+
+	if err := yaml.Unmarshal(b, &pluginsFile); err != nil {
+		return nil, fmt.Errorf("invalid plugins file: %w", err)
+	}
+
+	for i, spec := range pluginsFile.Plugins {
+		if spec.Path != "" && !filepath.IsAbs(spec.Path) {
+			pluginsFile.Plugins[i].Path = filepath.Join(filepath.Dir(filename), spec.Path)
+		}
+	}
+
+	return pluginsFile.Plugins, nil
+}
+
+type pluginTemplateData struct {
+	Name        string
+	Module      string
+	ClassName   string
+	Version     string
+	Description string
+	Author      string
+	Imports     string
+
+	Hook     bool
+	Tool     bool
+	Settings bool
+}
+
+func NewPluginsInitCmd() *cobra.Command {
+	type initCfg struct {
+		outputDir   string
+		version     string
+		description string
+		author      string
+		hook        bool
+		tool        bool
+		settings    bool
+		force       bool
+	}
+
+	cfg := &initCfg{}
+
+	pluginsInitCmd := &cobra.Command{
+		Use:           "init <name>",
+		Short:         "generate a new plugin skeleton",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cfg.hook && !cfg.tool && !cfg.settings {
+				return fmt.Errorf("at least one of --hook, --tool or --settings is needed")
+			}
+
+			module := pluginModuleName(args[0])
+			if module == "" {
+				return fmt.Errorf("invalid plugin name '%s'", args[0])
+			}
+
+			data := pluginTemplateData{
+				Name:        args[0],
+				Module:      module,
+				ClassName:   pluginClassName(module),
+				Version:     cfg.version,
+				Description: cfg.description,
+				Author:      cfg.author,
+				Hook:        cfg.hook,
+				Tool:        cfg.tool,
+				Settings:    cfg.settings,
+			}
+
+			imports := []string{}
+			if cfg.hook {
+				imports = append(imports, "hook")
+			}
+			if cfg.tool {
+				imports = append(imports, "tool")
+			}
+			if cfg.settings {
+				imports = append(imports, "plugin")
+			}
+			data.Imports = strings.Join(imports, ", ")
+
+			dir := filepath.Join(cfg.outputDir, module)
+			if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 && !cfg.force {
+				return fmt.Errorf("directory '%s' already exists and is not empty (use --force to overwrite)", dir)
+			}
+
+			files := map[string]string{
+				"plugin.json.tmpl":      "plugin.json",
+				"plugin.py.tmpl":        module + ".py",
+				"requirements.txt.tmpl": "requirements.txt",
+				"README.md.tmpl":        "README.md",
+			}
+
+			if err := renderPluginTemplates(dir, files, data); err != nil {
+				return err
+			}
+
+			fmt.Printf("plugin '%s' created in %s\n", data.Name, dir)
+			return nil
+		},
+	}
+
+	pluginsInitCmd.Flags().StringVarP(&cfg.outputDir, "output-dir", "o", ".", "The directory where the plugin folder will be created")
+	pluginsInitCmd.Flags().StringVar(&cfg.version, "version", "0.0.1", "The version of the plugin")
+	pluginsInitCmd.Flags().StringVar(&cfg.description, "description", "", "The description of the plugin")
+	pluginsInitCmd.Flags().StringVar(&cfg.author, "author", "", "The author of the plugin")
+	pluginsInitCmd.Flags().BoolVar(&cfg.hook, "hook", true, "Include an example hook")
+	pluginsInitCmd.Flags().BoolVar(&cfg.tool, "tool", true, "Include an example tool")
+	pluginsInitCmd.Flags().BoolVar(&cfg.settings, "settings", true, "Include an example settings model")
+	pluginsInitCmd.Flags().BoolVar(&cfg.force, "force", false, "Overwrite the files of an existing plugin folder")
+
+	return pluginsInitCmd
+}
+
+func renderPluginTemplates(dir string, files map[string]string, data pluginTemplateData) error {
+	funcs := template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}
+
+	tmpl, err := template.New("plugin").Funcs(funcs).ParseFS(pluginTemplates, "templates/plugin/*.tmpl")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for tmplName, fileName := range files {
+		f, err := os.Create(filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+
+		err = tmpl.ExecuteTemplate(f, tmplName, data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("rendering %s: %w", fileName, err)
+		}
+	}
+
+	return nil
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// pluginModuleName converts the plugin name into a valid Python module name (i.e. "My Plugin" -> "my_plugin")
+func pluginModuleName(name string) string {
+	module := nonIdentifierChars.ReplaceAllString(strings.ToLower(name), "_")
+	module = strings.Trim(module, "_")
+	if module != "" && module[0] >= '0' && module[0] <= '9' {
+		module = "plugin_" + module
+	}
+	return module
+}
+
+// pluginClassName converts the module name into a CamelCase name (i.e. "my_plugin" -> "MyPlugin")
+func pluginClassName(module string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(module, "_") {
+		if part == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+func NewPluginsConfigureCmd(catclient *cat.Client) *cobra.Command {
+	pluginsConfigureCmd := &cobra.Command{
+		Use:           "configure <plugin-id>",
+		Short:         "configure the settings of a plugin interactively",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Args:          cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			plugins, err := catclient.Plugins.Get(cmd.Context(), cat.PluginsGetOpts{})
+			if err != nil {
+				return []string{}, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			ids := []string{}
+			for _, plugin := range plugins {
+				ids = append(ids, plugin.ID)
+			}
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			settings, err := catclient.Plugins.GetSettings(ctx, args[0])
+			if err != nil {
+				return err
+			}
+
+			return configureSettings(args[0], settings.Schema, settings.Value, func(values map[string]any) error {
+				_, err := catclient.Plugins.UpdateSettings(ctx, args[0], values)
+				return err
+			})
+		},
+	}
+
+	return pluginsConfigureCmd
+}
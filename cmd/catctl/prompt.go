@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// confirm asks the user to type 'yes' to proceed with a destructive operation
+func confirm(format string, a ...any) (bool, error) {
+	fmt.Printf(format+" [type 'yes' to confirm]: ", a...)
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	return strings.TrimSpace(line) == "yes", nil
+}
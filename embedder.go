@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+type EmbedderService struct {
+	client *Client
+}
+
+type EmbedderSettings struct {
+	Settings              []*EmbedderSetting `json:"settings"`
+	SelectedConfiguration string             `json:"selected_configuration"`
+}
+
+type EmbedderSetting struct {
+	Name   string         `json:"name,omitempty"`
+	Value  map[string]any `json:"value,omitempty"`
+	Schema *LLMSchema     `json:"schema,omitempty"`
+}
+
+func (s *EmbedderService) Get(ctx context.Context) ([]*EmbedderSetting, error) {
+	settings, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Settings, nil
+}
+
+// GetAll returns the settings of every embedder, along with the name of the selected one
+func (s *EmbedderService) GetAll(ctx context.Context) (*EmbedderSettings, error) {
+	resp, err := get(ctx, s.client, "/embedder/settings", &EmbedderSettings{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+func (s *EmbedderService) GetByID(ctx context.Context, ID string) (*EmbedderSetting, error) {
+	resp, err := get(ctx, s.client, "/embedder/settings/"+ID, &EmbedderSetting{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// Update replaces the settings of the embedder, that becomes the selected one.
+// The settings are validated against the schema of the embedder before sending them, and unknown keys are rejected.
+func (s *EmbedderService) Update(ctx context.Context, ID string, req map[string]any) (*EmbedderSetting, error) {
+	setting, err := s.GetByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Schema != nil {
+		if err := setting.Schema.ValidateStrict(req); err != nil {
+			return nil, err
+		}
+	}
+	return s.update(ctx, ID, req)
+}
+
+func (s *EmbedderService) update(ctx context.Context, ID string, req map[string]any) (*EmbedderSetting, error) {
+	resp, err := put(ctx, s.client, "/embedder/settings/"+ID, req, &EmbedderSetting{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// UpdateValues updates the settings with the string values, coerced to the types of the embedder schema and validated
+func (s *EmbedderService) UpdateValues(ctx context.Context, ID string, values map[string]string) (*EmbedderSetting, error) {
+	setting, err := s.GetByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Schema == nil {
+		return nil, fmt.Errorf("missing schema of '%s'", ID)
+	}
+
+	req, err := setting.Schema.Coerce(values)
+	if err != nil {
+		return nil, err
+	}
+	if err := setting.Schema.Validate(req); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, ID, req)
+}
@@ -2,14 +2,18 @@ package client
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 )
 
 type LLMService struct {
 	client *Client
 }
 
-type llmSettingsResponse struct {
-	Settings []*LLMSetting `json:"settings"`
+type LLMSettings struct {
+	Settings              []*LLMSetting `json:"settings"`
+	SelectedConfiguration string        `json:"selected_configuration"`
 }
 
 type LLMSetting struct {
@@ -18,24 +22,24 @@ type LLMSetting struct {
 	Schema *LLMSchema     `json:"schema,omitempty"`
 }
 
-type LLMSchema struct {
-	HumanReadableName string                      `json:"humanReadableName"`
-	Description       string                      `json:"description"`
-	Properties        map[string]SchemaProperties `json:"properties"`
-}
+// LLMSchema is the JSON schema of the settings of an LLM
+type LLMSchema = Schema
 
-type SchemaProperties struct {
-	Title   string `json:"title"`
-	Type    string `json:"type"`
-	Default any    `json:"default"`
+func (s *LLMService) Get(ctx context.Context) ([]*LLMSetting, error) {
+	settings, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Settings, nil
 }
 
-func (s *LLMService) Get(ctx context.Context) ([]*LLMSetting, error) {
-	resp, err := get(ctx, s.client, "/llm/settings", llmSettingsResponse{})
+// GetAll returns the settings of every LLM, along with the name of the selected one
+func (s *LLMService) GetAll(ctx context.Context) (*LLMSettings, error) {
+	resp, err := get(ctx, s.client, "/llm/settings", &LLMSettings{})
 	if err != nil {
 		return nil, err
 	}
-	return resp.Value.Settings, nil
+	return resp.Value, nil
 }
 
 func (s *LLMService) GetByID(ctx context.Context, ID string) (*LLMSetting, error) {
@@ -46,10 +50,98 @@ func (s *LLMService) GetByID(ctx context.Context, ID string) (*LLMSetting, error
 	return resp.Value, nil
 }
 
-func (s *LLMService) Update(ctx context.Context, ID string, req map[string]any) (*Setting, error) {
-	resp, err := put(ctx, s.client, "/llm/settings/"+ID, req, settingResponse{})
+// Update replaces the settings of the LLM, that becomes the selected one.
+// The settings are validated against the schema of the LLM before sending them, and unknown keys are rejected.
+func (s *LLMService) Update(ctx context.Context, ID string, req map[string]any) (*LLMSetting, error) {
+	setting, err := s.GetByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Schema != nil {
+		if err := setting.Schema.ValidateStrict(req); err != nil {
+			return nil, err
+		}
+	}
+	return s.update(ctx, ID, req)
+}
+
+func (s *LLMService) update(ctx context.Context, ID string, req map[string]any) (*LLMSetting, error) {
+	resp, err := put(ctx, s.client, "/llm/settings/"+ID, req, &LLMSetting{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// UpdateValues updates the settings with the string values, coerced to the types of the LLM schema and validated
+func (s *LLMService) UpdateValues(ctx context.Context, ID string, values map[string]string) (*LLMSetting, error) {
+	setting, err := s.GetByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+	if setting.Schema == nil {
+		return nil, fmt.Errorf("missing schema of '%s'", ID)
+	}
+
+	req, err := setting.Schema.Coerce(values)
+	if err != nil {
+		return nil, err
+	}
+	if err := setting.Schema.Validate(req); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, ID, req)
+}
+
+// Use selects the LLM, with the values stored for it
+func (s *LLMService) Use(ctx context.Context, ID string) (*LLMSetting, error) {
+	setting, err := s.GetByID(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	value := setting.Value
+	if value == nil {
+		value = map[string]any{}
+	}
+	if setting.Schema != nil {
+		if err := setting.Schema.Validate(value); err != nil {
+			return nil, fmt.Errorf("the stored values of '%s' are not valid: %w", ID, err)
+		}
+	}
+	return s.update(ctx, ID, value)
+}
+
+// DefaultProbeMessage is the message sent by Test when none is given
+const DefaultProbeMessage = "Reply with a short greeting."
+
+// ProbeUserID is the user sending the probe messages when none is given, to keep them apart from the conversations
+const ProbeUserID = "llm_probe"
+
+// ErrEmptyReply is returned by Test when the LLM replies with an empty message
+var ErrEmptyReply = errors.New("empty reply from the LLM")
+
+type LLMTestOpts struct {
+	Message string
+	UserID  string
+}
+
+// Test sends a probe message to the selected LLM through the chat, checking that it replies.
+// An error frame of the Cat is returned as a *ChatError.
+func (s *LLMService) Test(ctx context.Context, opts LLMTestOpts) (*ChatReply, error) {
+	if opts.Message == "" {
+		opts.Message = DefaultProbeMessage
+	}
+	if opts.UserID == "" {
+		opts.UserID = ProbeUserID
+	}
+
+	reply, err := s.client.Chat.Send(ctx, opts.UserID, opts.Message)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Value.Setting, nil
+	if strings.TrimSpace(reply.Content) == "" {
+		return reply, ErrEmptyReply
+	}
+	return reply, nil
 }
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// LLMConfig is the typed configuration of an LLM provider, named as its settings in the Cat
+type LLMConfig interface {
+	LLMName() string
+}
+
+// Ptr returns a pointer to the value, to set the optional fields of the configurations
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+type LLMOpenAIChatConfig struct {
+	OpenAIAPIKey string   `json:"openai_api_key"`
+	ModelName    string   `json:"model_name,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	Streaming    *bool    `json:"streaming,omitempty"`
+}
+
+func (LLMOpenAIChatConfig) LLMName() string { return "LLMOpenAIChatConfig" }
+
+type LLMOpenAIConfig struct {
+	OpenAIAPIKey string   `json:"openai_api_key"`
+	ModelName    string   `json:"model_name,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	Streaming    *bool    `json:"streaming,omitempty"`
+}
+
+func (LLMOpenAIConfig) LLMName() string { return "LLMOpenAIConfig" }
+
+type LLMAzureOpenAIConfig struct {
+	OpenAIAPIKey   string `json:"openai_api_key"`
+	AzureEndpoint  string `json:"azure_endpoint"`
+	MaxTokens      *int   `json:"max_tokens,omitempty"`
+	APIType        string `json:"api_type,omitempty"`
+	APIVersion     string `json:"api_version,omitempty"`
+	DeploymentName string `json:"deployment_name,omitempty"`
+	ModelName      string `json:"model_name,omitempty"`
+	Streaming      *bool  `json:"streaming,omitempty"`
+}
+
+func (LLMAzureOpenAIConfig) LLMName() string { return "LLMAzureOpenAIConfig" }
+
+type LLMAzureChatOpenAIConfig struct {
+	OpenAIAPIKey     string `json:"openai_api_key"`
+	ModelName        string `json:"model_name,omitempty"`
+	AzureEndpoint    string `json:"azure_endpoint"`
+	MaxTokens        *int   `json:"max_tokens,omitempty"`
+	OpenAIAPIType    string `json:"openai_api_type,omitempty"`
+	OpenAIAPIVersion string `json:"openai_api_version,omitempty"`
+	AzureDeployment  string `json:"azure_deployment"`
+	Streaming        *bool  `json:"streaming,omitempty"`
+}
+
+func (LLMAzureChatOpenAIConfig) LLMName() string { return "LLMAzureChatOpenAIConfig" }
+
+type LLMOllamaConfig struct {
+	BaseURL       string   `json:"base_url"`
+	Model         string   `json:"model,omitempty"`
+	NumCtx        *int     `json:"num_ctx,omitempty"`
+	RepeatLastN   *int     `json:"repeat_last_n,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+}
+
+func (LLMOllamaConfig) LLMName() string { return "LLMOllamaConfig" }
+
+type LLMGeminiChatConfig struct {
+	GoogleAPIKey    string   `json:"google_api_key"`
+	Model           string   `json:"model,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *int     `json:"top_p,omitempty"`
+	TopK            *int     `json:"top_k,omitempty"`
+	MaxOutputTokens *int     `json:"max_output_tokens,omitempty"`
+}
+
+func (LLMGeminiChatConfig) LLMName() string { return "LLMGeminiChatConfig" }
+
+type LLMCohereConfig struct {
+	CohereAPIKey string   `json:"cohere_api_key"`
+	Model        string   `json:"model,omitempty"`
+	Temperature  *float64 `json:"temperature,omitempty"`
+	Streaming    *bool    `json:"streaming,omitempty"`
+}
+
+func (LLMCohereConfig) LLMName() string { return "LLMCohereConfig" }
+
+type LLMHuggingFaceEndpointConfig struct {
+	EndpointURL            string   `json:"endpoint_url"`
+	HuggingFaceHubAPIToken string   `json:"huggingfacehub_api_token"`
+	Task                   string   `json:"task,omitempty"`
+	MaxNewTokens           *int     `json:"max_new_tokens,omitempty"`
+	TopK                   *int     `json:"top_k,omitempty"`
+	TopP                   *float64 `json:"top_p,omitempty"`
+	Temperature            *float64 `json:"temperature,omitempty"`
+	RepetitionPenalty      *float64 `json:"repetition_penalty,omitempty"`
+}
+
+func (LLMHuggingFaceEndpointConfig) LLMName() string { return "LLMHuggingFaceEndpointConfig" }
+
+type LLMHuggingFaceTextGenInferenceConfig struct {
+	InferenceServerURL string   `json:"inference_server_url"`
+	MaxNewTokens       *int     `json:"max_new_tokens,omitempty"`
+	TopK               *int     `json:"top_k,omitempty"`
+	TopP               *float64 `json:"top_p,omitempty"`
+	TypicalP           *float64 `json:"typical_p,omitempty"`
+	Temperature        *float64 `json:"temperature,omitempty"`
+	RepetitionPenalty  *float64 `json:"repetition_penalty,omitempty"`
+}
+
+func (LLMHuggingFaceTextGenInferenceConfig) LLMName() string {
+	return "LLMHuggingFaceTextGenInferenceConfig"
+}
+
+type LLMOpenAICompatibleConfig struct {
+	URL         string   `json:"url"`
+	ModelName   string   `json:"model_name"`
+	APIKey      string   `json:"api_key"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Streaming   *bool    `json:"streaming,omitempty"`
+}
+
+func (LLMOpenAICompatibleConfig) LLMName() string { return "LLMOpenAICompatibleConfig" }
+
+// UpdateTyped updates the settings of the LLM of the configuration type
+func UpdateTyped[T LLMConfig](ctx context.Context, s *LLMService, config T) (*LLMSetting, error) {
+	req, err := toValue(config)
+	if err != nil {
+		return nil, err
+	}
+	return s.Update(ctx, config.LLMName(), req)
+}
+
+// GetTyped returns the stored settings of the LLM of the configuration type
+func GetTyped[T LLMConfig](ctx context.Context, s *LLMService) (T, error) {
+	var config T
+
+	setting, err := s.GetByID(ctx, config.LLMName())
+	if err != nil {
+		return config, err
+	}
+
+	b, err := json.Marshal(setting.Value)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return config, fmt.Errorf("decoding settings of '%s': %w", config.LLMName(), err)
+	}
+	return config, nil
+}
+
+// toValue converts the configuration to the value map sent to the Cat
+func toValue(config any) (map[string]any, error) {
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	value := map[string]any{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
@@ -0,0 +1,385 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// settingsServer serves the recorded settings payloads of a Cat, storing the values updated with PUT
+type settingsServer struct {
+	mu       sync.Mutex
+	settings map[string]map[string]json.RawMessage
+	payloads map[string][]byte
+}
+
+func newSettingsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	s := &settingsServer{
+		settings: map[string]map[string]json.RawMessage{},
+		payloads: map[string][]byte{},
+	}
+
+	for prefix, filename := range map[string]string{
+		"/llm/settings":      "testdata/llm_settings.json",
+		"/embedder/settings": "testdata/embedder_settings.json",
+	} {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.payloads[prefix] = b
+
+		payload := struct {
+			Settings []map[string]json.RawMessage `json:"settings"`
+		}{}
+		if err := json.Unmarshal(b, &payload); err != nil {
+			t.Fatalf("invalid payload '%s': %v", filename, err)
+		}
+		for _, setting := range payload.Settings {
+			var name string
+			if err := json.Unmarshal(setting["name"], &name); err != nil {
+				t.Fatalf("invalid setting name in '%s': %v", filename, err)
+			}
+			s.settings[prefix+"/"+name] = setting
+		}
+	}
+
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (s *settingsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if payload, found := s.payloads[r.URL.Path]; found && r.Method == http.MethodGet {
+		w.Write(payload)
+		return
+	}
+
+	setting, found := s.settings[r.URL.Path]
+	if !found {
+		http.Error(w, `{"detail":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(setting)
+
+	case http.MethodPut:
+		value := map[string]any{}
+		if err := json.NewDecoder(r.Body).Decode(&value); err != nil {
+			http.Error(w, `{"detail":"invalid body"}`, http.StatusBadRequest)
+			return
+		}
+		b, _ := json.Marshal(value)
+		setting["value"] = b
+
+		// the Cat replies with the name and the value, without the schema
+		json.NewEncoder(w).Encode(map[string]json.RawMessage{"name": setting["name"], "value": b})
+
+	default:
+		http.Error(w, `{"detail":"method not allowed"}`, http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	srv := newSettingsServer(t)
+	c, err := NewClient(WithHostname(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestLLMSettingsPayload(t *testing.T) {
+	c := newTestClient(t)
+
+	settings, err := c.LLM.GetAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.SelectedConfiguration != "LLMOllamaConfig" {
+		t.Errorf("selected configuration is '%s', expected 'LLMOllamaConfig'", settings.SelectedConfiguration)
+	}
+
+	names := map[string]bool{}
+	for _, setting := range settings.Settings {
+		names[setting.Name] = true
+
+		if setting.Schema == nil {
+			t.Errorf("missing schema of '%s'", setting.Name)
+			continue
+		}
+		if _, err := setting.Schema.Resolve(); err != nil {
+			t.Errorf("resolving schema of '%s': %v", setting.Name, err)
+		}
+		if setting.Schema.HumanReadableName == "" {
+			t.Errorf("missing humanReadableName of '%s'", setting.Name)
+		}
+	}
+
+	for _, config := range typedConfigs() {
+		if !names[config.LLMName()] {
+			t.Errorf("missing settings of '%s' in the payload", config.LLMName())
+		}
+	}
+
+	list, err := c.LLM.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(settings.Settings) {
+		t.Errorf("Get returned %d settings, GetAll %d", len(list), len(settings.Settings))
+	}
+}
+
+func TestEmbedderSettingsPayload(t *testing.T) {
+	c := newTestClient(t)
+
+	settings, err := c.Embedder.GetAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.SelectedConfiguration != "EmbedderFakeConfig" {
+		t.Errorf("selected configuration is '%s', expected 'EmbedderFakeConfig'", settings.SelectedConfiguration)
+	}
+	if len(settings.Settings) == 0 {
+		t.Fatal("no embedder settings decoded")
+	}
+
+	for _, setting := range settings.Settings {
+		if setting.Schema == nil {
+			t.Errorf("missing schema of '%s'", setting.Name)
+			continue
+		}
+		if err := setting.Schema.Validate(setting.Value); err != nil && setting.Name == settings.SelectedConfiguration {
+			t.Errorf("invalid value of the selected embedder '%s': %v", setting.Name, err)
+		}
+	}
+
+	list, err := c.Embedder.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(settings.Settings) {
+		t.Errorf("Get returned %d settings, GetAll %d", len(list), len(settings.Settings))
+	}
+}
+
+// typedConfigs returns every typed configuration, with all the fields set
+func typedConfigs() []LLMConfig {
+	return []LLMConfig{
+		LLMOpenAIChatConfig{
+			OpenAIAPIKey: "sk-chat",
+			ModelName:    "gpt-4o",
+			Temperature:  Ptr(0.2),
+			Streaming:    Ptr(false),
+		},
+		LLMOpenAIConfig{
+			OpenAIAPIKey: "sk-instruct",
+			ModelName:    "gpt-3.5-turbo-instruct",
+			Temperature:  Ptr(0.5),
+			Streaming:    Ptr(true),
+		},
+		LLMAzureOpenAIConfig{
+			OpenAIAPIKey:   "azure-key",
+			AzureEndpoint:  "https://example.openai.azure.com",
+			MaxTokens:      Ptr(1024),
+			APIType:        "azure",
+			APIVersion:     "2022-12-01",
+			DeploymentName: "instruct",
+			ModelName:      "gpt-35-turbo-instruct",
+			Streaming:      Ptr(false),
+		},
+		LLMAzureChatOpenAIConfig{
+			OpenAIAPIKey:     "azure-key",
+			ModelName:        "gpt-35-turbo",
+			AzureEndpoint:    "https://example.openai.azure.com",
+			MaxTokens:        Ptr(4096),
+			OpenAIAPIType:    "azure",
+			OpenAIAPIVersion: "2023-05-15",
+			AzureDeployment:  "chat",
+			Streaming:        Ptr(true),
+		},
+		LLMOllamaConfig{
+			BaseURL:       "http://localhost:11434",
+			Model:         "mistral",
+			NumCtx:        Ptr(4096),
+			RepeatLastN:   Ptr(32),
+			RepeatPenalty: Ptr(1.2),
+			Temperature:   Ptr(0.3),
+		},
+		LLMGeminiChatConfig{
+			GoogleAPIKey:    "google-key",
+			Model:           "gemini-1.5-flash",
+			Temperature:     Ptr(0.4),
+			TopP:            Ptr(1),
+			TopK:            Ptr(40),
+			MaxOutputTokens: Ptr(2048),
+		},
+		LLMCohereConfig{
+			CohereAPIKey: "cohere-key",
+			Model:        "command-r",
+			Temperature:  Ptr(0.6),
+			Streaming:    Ptr(false),
+		},
+		LLMHuggingFaceEndpointConfig{
+			EndpointURL:            "https://example.endpoints.huggingface.cloud",
+			HuggingFaceHubAPIToken: "hf-token",
+			Task:                   "text-generation",
+			MaxNewTokens:           Ptr(256),
+			TopK:                   Ptr(10),
+			TopP:                   Ptr(0.9),
+			Temperature:            Ptr(0.7),
+			RepetitionPenalty:      Ptr(1.1),
+		},
+		LLMHuggingFaceTextGenInferenceConfig{
+			InferenceServerURL: "http://tgi:8080",
+			MaxNewTokens:       Ptr(256),
+			TopK:               Ptr(20),
+			TopP:               Ptr(0.9),
+			TypicalP:           Ptr(0.9),
+			Temperature:        Ptr(0.1),
+			RepetitionPenalty:  Ptr(1.05),
+		},
+		LLMOpenAICompatibleConfig{
+			URL:         "http://llama:8000/v1",
+			ModelName:   "llama-3",
+			APIKey:      "compatible-key",
+			Temperature: Ptr(0.01),
+			Streaming:   Ptr(true),
+		},
+	}
+}
+
+func TestTypedConfigsRoundTrip(t *testing.T) {
+	c := newTestClient(t)
+
+	for _, config := range typedConfigs() {
+		t.Run(config.LLMName(), func(t *testing.T) {
+			switch config := config.(type) {
+			case LLMOpenAIChatConfig:
+				roundTrip(t, c, config)
+			case LLMOpenAIConfig:
+				roundTrip(t, c, config)
+			case LLMAzureOpenAIConfig:
+				roundTrip(t, c, config)
+			case LLMAzureChatOpenAIConfig:
+				roundTrip(t, c, config)
+			case LLMOllamaConfig:
+				roundTrip(t, c, config)
+			case LLMGeminiChatConfig:
+				roundTrip(t, c, config)
+			case LLMCohereConfig:
+				roundTrip(t, c, config)
+			case LLMHuggingFaceEndpointConfig:
+				roundTrip(t, c, config)
+			case LLMHuggingFaceTextGenInferenceConfig:
+				roundTrip(t, c, config)
+			case LLMOpenAICompatibleConfig:
+				roundTrip(t, c, config)
+			default:
+				t.Fatalf("missing round trip of %T", config)
+			}
+		})
+	}
+}
+
+// roundTrip updates the LLM with the typed configuration, and checks that it's read back unchanged
+func roundTrip[T LLMConfig](t *testing.T, c *Client, config T) {
+	t.Helper()
+	ctx := context.Background()
+
+	updated, err := UpdateTyped(ctx, c.LLM, config)
+	if err != nil {
+		t.Fatalf("UpdateTyped: %v", err)
+	}
+	if updated.Name != config.LLMName() {
+		t.Errorf("updated '%s', expected '%s'", updated.Name, config.LLMName())
+	}
+
+	// every field is sent, with the JSON name of the schema property
+	setting, err := c.LLM.GetByID(ctx, config.LLMName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range setting.Value {
+		if _, found := setting.Schema.Properties[key]; !found {
+			t.Errorf("property '%s' is not in the schema of '%s'", key, config.LLMName())
+		}
+	}
+	if len(setting.Value) != reflect.TypeOf(config).NumField() {
+		t.Errorf("sent %d properties, the configuration has %d fields", len(setting.Value), reflect.TypeOf(config).NumField())
+	}
+
+	got, err := GetTyped[T](ctx, c.LLM)
+	if err != nil {
+		t.Fatalf("GetTyped: %v", err)
+	}
+	if !reflect.DeepEqual(got, config) {
+		t.Errorf("read back %+v, expected %+v", got, config)
+	}
+}
+
+func TestGetTypedRecordedValues(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	// the recorded values have every property set, so that they're encoded back the same
+	ollama, err := GetTyped[LLMOllamaConfig](ctx, c.LLM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setting, err := c.LLM.GetByID(ctx, ollama.LLMName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err := toValue(ollama)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(value, setting.Value) {
+		t.Errorf("typed value %v differs from the recorded one %v", value, setting.Value)
+	}
+
+	// the optional fields missing from the stored values are left unset
+	openai, err := GetTyped[LLMOpenAIConfig](ctx, c.LLM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if openai.Temperature != nil || openai.Streaming != nil {
+		t.Errorf("unexpected optional fields in %+v", openai)
+	}
+}
+
+func TestUpdateInvalid(t *testing.T) {
+	c := newTestClient(t)
+
+	// the values are validated against the recorded schema before sending them
+	_, err := c.LLM.Update(context.Background(), "LLMCohereConfig", map[string]any{"temperature": "hot"})
+	if err == nil || !strings.Contains(err.Error(), "cohere_api_key") || !strings.Contains(err.Error(), "'temperature'") {
+		t.Errorf("expected the missing property and the type errors, got %v", err)
+	}
+
+	// a misspelled key would be dropped by the Cat
+	typo := map[string]any{"base_url": "http://localhost:11434", "model": "mistral", "temprature": 0.2}
+	if _, err := c.LLM.Update(context.Background(), "LLMOllamaConfig", typo); err == nil || !strings.Contains(err.Error(), "unknown property 'temprature'") {
+		t.Errorf("expected the unknown property error, got %v", err)
+	}
+	if _, err := c.Embedder.Update(context.Background(), "EmbedderFakeConfig", map[string]any{"sise": 128}); err == nil || !strings.Contains(err.Error(), "unknown property 'sise'") {
+		t.Errorf("expected the unknown property error, got %v", err)
+	}
+}
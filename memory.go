@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type MemoryService struct {
+	client *Client
+}
+
+type Collection struct {
+	Name         string `json:"name"`
+	VectorsCount int    `json:"vectors_count"`
+}
+
+type collectionsResponse struct {
+	Collections []*Collection `json:"collections"`
+}
+
+type MemoryPoint struct {
+	ID          string         `json:"id"`
+	PageContent string         `json:"page_content"`
+	Metadata    map[string]any `json:"metadata,omitempty"`
+	Score       float64        `json:"score,omitempty"`
+	Vector      []float64      `json:"vector,omitempty"`
+}
+
+type RecallQuery struct {
+	Text   string    `json:"text"`
+	Vector []float64 `json:"vector"`
+}
+
+type RecallResult struct {
+	Query       RecallQuery
+	Embedder    string
+	Collections map[string][]*MemoryPoint
+}
+
+type recallResponse struct {
+	Query   RecallQuery `json:"query"`
+	Vectors struct {
+		Embedder    string                    `json:"embedder"`
+		Collections map[string][]*MemoryPoint `json:"collections"`
+	} `json:"vectors"`
+}
+
+type deletedResponse struct {
+	Deleted map[string]bool `json:"deleted"`
+}
+
+type MemoryPointCreateRequest struct {
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+type PointsGetOpts struct {
+	Limit  int
+	Offset string
+}
+
+// PointsPage is a page of the points of a collection. NextOffset is empty when there are no more points.
+type PointsPage struct {
+	Points     []*MemoryPoint
+	NextOffset string
+}
+
+type memoryPointResponse struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata"`
+	Vector   []float64      `json:"vector"`
+}
+
+type pointsResponse struct {
+	Points []struct {
+		ID      string `json:"id"`
+		Payload struct {
+			PageContent string         `json:"page_content"`
+			Metadata    map[string]any `json:"metadata"`
+		} `json:"payload"`
+		Vector []float64 `json:"vector"`
+	} `json:"points"`
+	NextOffset *string `json:"next_offset"`
+}
+
+// Collections returns the memory collections, with the number of vectors stored in each of them
+func (s *MemoryService) Collections(ctx context.Context) ([]*Collection, error) {
+	resp, err := get(ctx, s.client, "/memory/collections", collectionsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value.Collections, nil
+}
+
+// Recall searches the k most similar points to the text, in every collection
+func (s *MemoryService) Recall(ctx context.Context, text string, k int) (*RecallResult, error) {
+	values := url.Values{}
+	values.Set("text", text)
+	if k > 0 {
+		values.Set("k", strconv.Itoa(k))
+	}
+
+	resp, err := get(ctx, s.client, "/memory/recall?"+values.Encode(), recallResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecallResult{
+		Query:       resp.Value.Query,
+		Embedder:    resp.Value.Vectors.Embedder,
+		Collections: resp.Value.Vectors.Collections,
+	}, nil
+}
+
+// WipeCollection deletes all the points of a collection
+func (s *MemoryService) WipeCollection(ctx context.Context, collection string) error {
+	_, err := del(ctx, s.client, collectionPath(collection), deletedResponse{})
+	return err
+}
+
+// WipeAll deletes all the points of every collection
+func (s *MemoryService) WipeAll(ctx context.Context) error {
+	_, err := del(ctx, s.client, "/memory/collections", deletedResponse{})
+	return err
+}
+
+// CreatePoint embeds the content and stores it in the collection, with the provided metadata
+func (s *MemoryService) CreatePoint(ctx context.Context, collection string, req MemoryPointCreateRequest) (*MemoryPoint, error) {
+	if req.Metadata == nil {
+		req.Metadata = map[string]any{}
+	}
+
+	resp, err := post(ctx, s.client, collectionPath(collection)+"/points", req, memoryPointResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MemoryPoint{
+		ID:          resp.Value.ID,
+		PageContent: resp.Value.Content,
+		Metadata:    resp.Value.Metadata,
+		Vector:      resp.Value.Vector,
+	}, nil
+}
+
+// Points returns a page of the points stored in the collection
+func (s *MemoryService) Points(ctx context.Context, collection string, opts PointsGetOpts) (*PointsPage, error) {
+	endpoint := collectionPath(collection) + "/points"
+
+	values := url.Values{}
+	if opts.Limit > 0 {
+		values.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset != "" {
+		values.Set("offset", opts.Offset)
+	}
+
+	if len(values) > 0 {
+		endpoint += "?" + values.Encode()
+	}
+
+	resp, err := get(ctx, s.client, endpoint, pointsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PointsPage{}
+	for _, p := range resp.Value.Points {
+		page.Points = append(page.Points, &MemoryPoint{
+			ID:          p.ID,
+			PageContent: p.Payload.PageContent,
+			Metadata:    p.Payload.Metadata,
+			Vector:      p.Vector,
+		})
+	}
+	if resp.Value.NextOffset != nil {
+		page.NextOffset = *resp.Value.NextOffset
+	}
+
+	return page, nil
+}
+
+// ErrStopPaging can be returned by the function passed to AllPoints to stop paging without an error
+var ErrStopPaging = errors.New("stop paging")
+
+// AllPoints pages through all the points of the collection, calling fn for each page
+func (s *MemoryService) AllPoints(ctx context.Context, collection string, pageSize int, fn func(points []*MemoryPoint) error) error {
+	opts := PointsGetOpts{Limit: pageSize}
+
+	for {
+		page, err := s.Points(ctx, collection, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(page.Points); err != nil {
+			if errors.Is(err, ErrStopPaging) {
+				return nil
+			}
+			return err
+		}
+
+		if page.NextOffset == "" {
+			return nil
+		}
+		opts.Offset = page.NextOffset
+	}
+}
+
+func (s *MemoryService) DeletePoint(ctx context.Context, collection, ID string) error {
+	_, err := del(ctx, s.client, collectionPath(collection)+"/points/"+url.PathEscape(ID), map[string]any{})
+	return err
+}
+
+// DeletePointsByMetadata deletes all the points of the collection matching the metadata filter (i.e. source=handbook.pdf)
+func (s *MemoryService) DeletePointsByMetadata(ctx context.Context, collection string, metadata map[string]any) error {
+	_, err := do(ctx, s.client, http.MethodDelete, collectionPath(collection)+"/points", metadata, map[string]any{})
+	return err
+}
+
+// EmbedderInfo describes the embedder currently used by the Cat
+type EmbedderInfo struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// Embedder returns the name and the vector dimensions of the embedder in use, embedding a probe query
+func (s *MemoryService) Embedder(ctx context.Context) (*EmbedderInfo, error) {
+	result, err := s.Recall(ctx, "meow", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmbedderInfo{
+		Name: result.Embedder,
+		Size: len(result.Query.Vector),
+	}, nil
+}
+
+// collectionPath returns the endpoint of the collection, with its name escaped
+func collectionPath(collection string) string {
+	return "/memory/collections/" + url.PathEscape(collection)
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultUserID is the user of the requests without an explicit user
+const DefaultUserID = "user"
+
+// ErrIngestionTimeout is returned when the end of an ingestion is not notified within the wait timeout
+var ErrIngestionTimeout = errors.New("timeout waiting for the end of the ingestion")
+
+// wsMessage is a frame sent by the Cat on the websocket of a user
+type wsMessage struct {
+	Type        string `json:"type"`
+	Content     string `json:"content"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// userListener reads the frames of a user websocket, and dispatches them to its subscriptions.
+// The Cat keeps a single websocket for each user, so concurrent uploads share the same listener.
+type userListener struct {
+	conn *websocket.Conn
+	subs map[*subscription]struct{}
+	done chan struct{}
+	err  error
+}
+
+// subscription receives the frames about the ingestion of a source
+type subscription struct {
+	source string
+	msgs   chan wsMessage
+	closed chan struct{}
+}
+
+type notifications struct {
+	mu        sync.Mutex
+	listeners map[string]*userListener
+}
+
+// subscribe starts receiving the messages about the source sent on the websocket of the user, connecting to it if needed.
+// The Cat notifies the end of an ingestion only by the source name, so a subscription waits for the one
+// of the same source to be closed before starting. The returned func must be called to stop receiving them.
+func (n *notifications) subscribe(ctx context.Context, c *Client, userID, source string) (*subscription, *userListener, func(), error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.listeners == nil {
+		n.listeners = map[string]*userListener{}
+	}
+
+	for {
+		l, found := n.listeners[userID]
+		if !found {
+			break
+		}
+
+		// a listener whose websocket was closed is dropped, and the next subscriptions connect again
+		select {
+		case <-l.done:
+			delete(n.listeners, userID)
+			l.conn.Close()
+			continue
+		default:
+		}
+
+		busy := l.subscription(source)
+		if busy == nil {
+			break
+		}
+
+		n.mu.Unlock()
+		select {
+		case <-busy.closed:
+			n.mu.Lock()
+		case <-ctx.Done():
+			n.mu.Lock()
+			return nil, nil, nil, ctx.Err()
+		}
+	}
+
+	l, found := n.listeners[userID]
+	if !found {
+		conn, err := c.dialWS(ctx, "/ws/"+userID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("connecting to the websocket of '%s': %w", userID, err)
+		}
+
+		l = &userListener{
+			conn: conn,
+			subs: map[*subscription]struct{}{},
+			done: make(chan struct{}),
+		}
+		n.listeners[userID] = l
+		go n.listen(l)
+	}
+
+	sub := &subscription{
+		source: source,
+		msgs:   make(chan wsMessage, 16),
+		closed: make(chan struct{}),
+	}
+	l.subs[sub] = struct{}{}
+
+	unsubscribe := func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		delete(l.subs, sub)
+		close(sub.closed)
+
+		if len(l.subs) == 0 && n.listeners[userID] == l {
+			delete(n.listeners, userID)
+			l.conn.Close()
+		}
+	}
+
+	return sub, l, unsubscribe, nil
+}
+
+// subscription returns the subscription of the source, if any
+func (l *userListener) subscription(source string) *subscription {
+	for sub := range l.subs {
+		if sub.source == source {
+			return sub
+		}
+	}
+	return nil
+}
+
+func (n *notifications) listen(l *userListener) {
+	defer close(l.done)
+
+	for {
+		_, message, err := l.conn.ReadMessage()
+		if err != nil {
+			l.err = err
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(message, &msg); err != nil {
+			continue
+		}
+
+		n.mu.Lock()
+		subs := recipients(l, msg)
+		n.mu.Unlock()
+
+		for _, sub := range subs {
+			select {
+			case sub.msgs <- msg:
+			case <-sub.closed:
+			}
+		}
+	}
+}
+
+// recipients returns the subscriptions a message is dispatched to.
+// Error frames don't carry the source, so they go to the subscriptions whose source is mentioned in the error,
+// or to the only subscription. Errors that can't be attributed are dropped, and the waits end with a timeout.
+func recipients(l *userListener, msg wsMessage) []*subscription {
+	subs := make([]*subscription, 0, len(l.subs))
+	for sub := range l.subs {
+		if msg.Type != "error" || len(l.subs) == 1 || strings.Contains(msg.Description, sub.source) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// waitIngestion waits for the notification of the end of the ingestion of the source, or for an error frame
+func waitIngestion(ctx context.Context, sub *subscription, l *userListener, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	// the Cat notifies "Finished reading <source>, I made <n> thoughts on it."
+	finished := "Finished reading " + sub.source + ", "
+
+	for {
+		select {
+		case msg := <-sub.msgs:
+			switch msg.Type {
+			case "notification":
+				if strings.HasPrefix(msg.Content, finished) {
+					return nil
+				}
+			case "error":
+				return fmt.Errorf("ingestion of '%s' failed: %s %s", sub.source, msg.Name, msg.Description)
+			}
+
+		case <-l.done:
+			return fmt.Errorf("websocket closed while waiting for '%s': %w", sub.source, l.err)
+
+		case <-timer.C:
+			return ErrIngestionTimeout
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// CorePluginID is the ID of the plugin shipped with the Cat, that cannot be removed or disabled
+const CorePluginID = "core_plugin"
+
+type PluginsService struct {
+	client *Client
+}
+
+type Plugin struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	AuthorName  string        `json:"author_name,omitempty"`
+	AuthorURL   string        `json:"author_url,omitempty"`
+	PluginURL   string        `json:"plugin_url,omitempty"`
+	Tags        string        `json:"tags,omitempty"`
+	Thumb       string        `json:"thumb,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	Active      bool          `json:"active"`
+	URL         string        `json:"url,omitempty"`
+	Hooks       []*PluginHook `json:"hooks,omitempty"`
+	Tools       []*PluginTool `json:"tools,omitempty"`
+}
+
+type PluginHook struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+type PluginTool struct {
+	Name string `json:"name"`
+}
+
+type pluginsResponse struct {
+	Installed []*Plugin `json:"installed"`
+	Registry  []*Plugin `json:"registry"`
+}
+
+type pluginResponse struct {
+	Data *Plugin `json:"data"`
+}
+
+type PluginsGetOpts struct {
+	Query string
+}
+
+func (s *PluginsService) list(ctx context.Context, opts PluginsGetOpts) (*pluginsResponse, error) {
+	endpoint := "/plugins"
+
+	values := url.Values{}
+	if opts.Query != "" {
+		values.Set("query", opts.Query)
+	}
+
+	if len(values) > 0 {
+		endpoint += "?" + values.Encode()
+	}
+
+	resp, err := get(ctx, s.client, endpoint, pluginsResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Value, nil
+}
+
+// Get returns the installed plugins
+func (s *PluginsService) Get(ctx context.Context, opts PluginsGetOpts) ([]*Plugin, error) {
+	resp, err := s.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Installed, nil
+}
+
+// Registry returns the plugins available in the public registry
+func (s *PluginsService) Registry(ctx context.Context, opts PluginsGetOpts) ([]*Plugin, error) {
+	resp, err := s.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Registry, nil
+}
+
+func (s *PluginsService) GetByID(ctx context.Context, ID string) (*Plugin, error) {
+	resp, err := get(ctx, s.client, "/plugins/"+ID, pluginResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value.Data, nil
+}
+
+// Toggle activates an inactive plugin, or deactivates an active one
+func (s *PluginsService) Toggle(ctx context.Context, ID string) error {
+	_, err := put(ctx, s.client, "/plugins/toggle/"+ID, nil, map[string]any{})
+	return err
+}
+
+// Install uploads a zip archive of a plugin. If the plugin is already installed it gets replaced.
+func (s *PluginsService) Install(ctx context.Context, filename string, r io.Reader) error {
+	_, err := postMultipart(ctx, s.client, "/plugins/upload", http.Header{}, func(w *multipart.Writer) error {
+		part, err := createFormFile(w, "file", filename)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, r)
+		return err
+	}, map[string]any{})
+	return err
+}
+
+// InstallFromRegistry installs the plugin available at the provided registry URL
+func (s *PluginsService) InstallFromRegistry(ctx context.Context, pluginURL string) error {
+	req := map[string]string{"url": pluginURL}
+	_, err := post(ctx, s.client, "/plugins/upload/registry", req, map[string]any{})
+	return err
+}
+
+func (s *PluginsService) Delete(ctx context.Context, ID string) error {
+	_, err := del(ctx, s.client, "/plugins/"+ID, map[string]any{})
+	return err
+}
+
+type PluginSettings struct {
+	Name   string         `json:"name,omitempty"`
+	Value  map[string]any `json:"value,omitempty"`
+	Schema *Schema        `json:"schema,omitempty"`
+}
+
+// GetSettings returns the settings of a plugin, with their schema
+func (s *PluginsService) GetSettings(ctx context.Context, ID string) (*PluginSettings, error) {
+	resp, err := get(ctx, s.client, "/plugins/settings/"+ID, &PluginSettings{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// UpdateSettings replaces the settings of a plugin
+func (s *PluginsService) UpdateSettings(ctx context.Context, ID string, req map[string]any) (*PluginSettings, error) {
+	resp, err := put(ctx, s.client, "/plugins/settings/"+ID, req, &PluginSettings{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+// PluginSpec describes the desired state of a plugin.
+// A plugin that needs to be installed or upgraded is fetched from its Path (a local zip archive) or from its registry URL.
+type PluginSpec struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+	Active  *bool  `json:"active,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+func (p PluginSpec) active() bool {
+	return p.Active == nil || *p.Active
+}
+
+type PluginActionType string
+
+const (
+	PluginActionInstall PluginActionType = "install"
+	PluginActionUpgrade PluginActionType = "upgrade"
+	PluginActionRemove  PluginActionType = "remove"
+	PluginActionEnable  PluginActionType = "enable"
+	PluginActionDisable PluginActionType = "disable"
+)
+
+// PluginAction is a single step needed to bring the installed plugins to the desired state
+type PluginAction struct {
+	Type        PluginActionType
+	PluginID    string
+	FromVersion string
+	ToVersion   string
+	Spec        PluginSpec
+}
+
+// Plan compares the installed plugins with the desired ones, and returns the actions needed to reconcile them.
+// Installed plugins not listed in desired are removed, with the exception of the core plugin.
+func (s *PluginsService) Plan(ctx context.Context, desired []PluginSpec) ([]PluginAction, error) {
+	installed, err := s.Get(ctx, PluginsGetOpts{})
+	if err != nil {
+		return nil, err
+	}
+
+	installedByID := map[string]*Plugin{}
+	for _, plugin := range installed {
+		installedByID[plugin.ID] = plugin
+	}
+
+	desiredByID := map[string]PluginSpec{}
+	for _, spec := range desired {
+		if spec.ID == "" {
+			return nil, fmt.Errorf("missing id in plugin spec")
+		}
+		if _, found := desiredByID[spec.ID]; found {
+			return nil, fmt.Errorf("duplicated plugin '%s'", spec.ID)
+		}
+		desiredByID[spec.ID] = spec
+	}
+
+	plan := []PluginAction{}
+
+	for _, plugin := range installed {
+		if _, found := desiredByID[plugin.ID]; !found && plugin.ID != CorePluginID {
+			plan = append(plan, PluginAction{
+				Type:        PluginActionRemove,
+				PluginID:    plugin.ID,
+				FromVersion: plugin.Version,
+			})
+		}
+	}
+
+	for _, spec := range desired {
+		plugin, found := installedByID[spec.ID]
+
+		// newly installed plugins are activated by the Cat, while the upgraded ones keep their state
+		active := true
+
+		switch {
+		case !found:
+			if spec.URL == "" && spec.Path == "" {
+				return nil, fmt.Errorf("plugin '%s' is not installed and has no url or path to install it from", spec.ID)
+			}
+			plan = append(plan, PluginAction{
+				Type:      PluginActionInstall,
+				PluginID:  spec.ID,
+				ToVersion: spec.Version,
+				Spec:      spec,
+			})
+
+		case spec.Version != "" && spec.Version != plugin.Version:
+			if spec.URL == "" && spec.Path == "" {
+				return nil, fmt.Errorf("plugin '%s' needs an upgrade but has no url or path to install it from", spec.ID)
+			}
+			plan = append(plan, PluginAction{
+				Type:        PluginActionUpgrade,
+				PluginID:    spec.ID,
+				FromVersion: plugin.Version,
+				ToVersion:   spec.Version,
+				Spec:        spec,
+			})
+			active = plugin.Active
+
+		default:
+			active = plugin.Active
+		}
+
+		if spec.ID == CorePluginID || active == spec.active() {
+			continue
+		}
+
+		actionType := PluginActionEnable
+		if !spec.active() {
+			actionType = PluginActionDisable
+		}
+		plan = append(plan, PluginAction{
+			Type:      actionType,
+			PluginID:  spec.ID,
+			ToVersion: spec.Version,
+			Spec:      spec,
+		})
+	}
+
+	return plan, nil
+}
+
+// Apply executes the actions of a plan, in order. It returns the actions that were successfully applied.
+func (s *PluginsService) Apply(ctx context.Context, plan []PluginAction) ([]PluginAction, error) {
+	applied := []PluginAction{}
+
+	for _, action := range plan {
+		var err error
+
+		switch action.Type {
+		case PluginActionInstall, PluginActionUpgrade:
+			err = s.installSpec(ctx, action.Spec)
+		case PluginActionRemove:
+			err = s.Delete(ctx, action.PluginID)
+		case PluginActionEnable, PluginActionDisable:
+			err = s.Toggle(ctx, action.PluginID)
+		default:
+			err = fmt.Errorf("unknown action '%s'", action.Type)
+		}
+
+		if err != nil {
+			return applied, fmt.Errorf("%s plugin '%s': %w", action.Type, action.PluginID, err)
+		}
+		applied = append(applied, action)
+	}
+
+	return applied, nil
+}
+
+// Reconcile installs, upgrades, removes and toggles the plugins so that the installed ones match exactly the desired ones.
+// After applying the plan the installed plugins are checked against the desired ones.
+func (s *PluginsService) Reconcile(ctx context.Context, desired []PluginSpec) ([]PluginAction, error) {
+	plan, err := s.Plan(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := s.Apply(ctx, plan)
+	if err != nil {
+		return applied, err
+	}
+
+	return applied, s.Verify(ctx, desired)
+}
+
+// Verify checks that the installed plugins are exactly the desired ones, with their versions and state.
+// The core plugin is the only one that can be installed without being desired.
+func (s *PluginsService) Verify(ctx context.Context, desired []PluginSpec) error {
+	installed, err := s.Get(ctx, PluginsGetOpts{})
+	if err != nil {
+		return err
+	}
+
+	desiredByID := map[string]PluginSpec{}
+	for _, spec := range desired {
+		desiredByID[spec.ID] = spec
+	}
+
+	installedByID := map[string]*Plugin{}
+	for _, plugin := range installed {
+		installedByID[plugin.ID] = plugin
+
+		if _, found := desiredByID[plugin.ID]; !found && plugin.ID != CorePluginID {
+			return fmt.Errorf("plugin '%s' is still installed after removal", plugin.ID)
+		}
+	}
+
+	for _, spec := range desired {
+		plugin, found := installedByID[spec.ID]
+		if !found {
+			return fmt.Errorf("plugin '%s' not found after install", spec.ID)
+		}
+		if spec.Version != "" && spec.Version != plugin.Version {
+			return fmt.Errorf("plugin '%s' has version '%s' after install, expected '%s'", spec.ID, plugin.Version, spec.Version)
+		}
+		if spec.ID != CorePluginID && spec.active() != plugin.Active {
+			return fmt.Errorf("plugin '%s' has active '%t' after install, expected '%t'", spec.ID, plugin.Active, spec.active())
+		}
+	}
+
+	return nil
+}
+
+func (s *PluginsService) installSpec(ctx context.Context, spec PluginSpec) error {
+	if spec.Path == "" {
+		return s.InstallFromRegistry(ctx, spec.URL)
+	}
+
+	f, err := os.Open(spec.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.Install(ctx, filepath.Base(spec.Path), f)
+}
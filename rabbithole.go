@@ -0,0 +1,362 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type RabbitHoleService struct {
+	client        *Client
+	notifications notifications
+}
+
+// UploadOpts are the ingestion parameters. Zero values are left to the Cat defaults.
+type UploadOpts struct {
+	ChunkSize    int
+	ChunkOverlap int
+	Metadata     map[string]any
+
+	// UserID is the user notified about the ingestion (default DefaultUserID)
+	UserID string
+	// WaitTimeout, if set, makes the upload listen on the websocket of the user, and block until the
+	// ingestion is notified as finished, an error arrives or the timeout expires (ErrIngestionTimeout).
+	WaitTimeout time.Duration
+}
+
+func (o UploadOpts) userID() string {
+	if o.UserID == "" {
+		return DefaultUserID
+	}
+	return o.UserID
+}
+
+func (o UploadOpts) header() http.Header {
+	header := http.Header{}
+	header.Set("user_id", o.userID())
+	return header
+}
+
+type UploadResponse struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Info        string `json:"info"`
+}
+
+// UploadFile sends a file to the rabbit hole, to be chunked and stored in the declarative memory.
+// The content is streamed from the reader without buffering it.
+// If the upload waits for the ingestion, the response is returned along with the error of the ingestion.
+func (s *RabbitHoleService) UploadFile(ctx context.Context, name string, r io.Reader, opts UploadOpts) (*UploadResponse, error) {
+	wait, stop, err := s.watch(ctx, opts, name)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	resp, err := postMultipart(ctx, s.client, "/rabbithole/", opts.header(), func(w *multipart.Writer) error {
+		if err := writeUploadOpts(w, opts); err != nil {
+			return err
+		}
+
+		part, err := createFormFile(w, "file", name)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, r)
+		return err
+	}, &UploadResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, wait()
+}
+
+type UploadURLResponse struct {
+	URL  string `json:"url"`
+	Info string `json:"info"`
+}
+
+type uploadURLRequest struct {
+	URL          string         `json:"url"`
+	ChunkSize    int            `json:"chunk_size,omitempty"`
+	ChunkOverlap int            `json:"chunk_overlap,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+// UploadURL asks the Cat to scrape a web page, and to store its content in the declarative memory
+func (s *RabbitHoleService) UploadURL(ctx context.Context, url string, opts UploadOpts) (*UploadURLResponse, error) {
+	req := uploadURLRequest{
+		URL:          url,
+		ChunkSize:    opts.ChunkSize,
+		ChunkOverlap: opts.ChunkOverlap,
+		Metadata:     opts.Metadata,
+	}
+
+	wait, stop, err := s.watch(ctx, opts, url)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	resp, err := doWithHeader(ctx, s.client, http.MethodPost, "/rabbithole/web", req, opts.header(), &UploadURLResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, wait()
+}
+
+// watch subscribes to the notifications of the user, if the options ask to wait for the end of the ingestion.
+// It returns the func that waits for the ingestion of the source, and the one to stop watching.
+func (s *RabbitHoleService) watch(ctx context.Context, opts UploadOpts, source string) (func() error, func(), error) {
+	if opts.WaitTimeout <= 0 {
+		return func() error { return nil }, func() {}, nil
+	}
+
+	sub, l, unsubscribe, err := s.notifications.subscribe(ctx, s.client, opts.userID(), source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wait := func() error {
+		return waitIngestion(ctx, sub, l, opts.WaitTimeout)
+	}
+	return wait, unsubscribe, nil
+}
+
+// MemoryFile is the format of the declarative memories exported by the Cat
+type MemoryFile struct {
+	Embedder    EmbedderInfo              `json:"embedder"`
+	Collections map[string][]*MemoryPoint `json:"collections"`
+}
+
+// Validate checks the structure of the memory file: only declarative memories can be uploaded,
+// and each of them needs a content and a vector of the size of the embedder.
+func (m *MemoryFile) Validate() error {
+	if m.Embedder.Name == "" || m.Embedder.Size <= 0 {
+		return fmt.Errorf("missing embedder name or size")
+	}
+
+	declarative, found := m.Collections["declarative"]
+	if !found {
+		return fmt.Errorf("missing declarative collection")
+	}
+
+	for i, point := range declarative {
+		if err := point.ValidateDeclarative(m.Embedder.Size); err != nil {
+			return fmt.Errorf("declarative memory %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateDeclarative checks that the point can be uploaded in a MemoryFile, with a vector of the embedder size
+func (p *MemoryPoint) ValidateDeclarative(size int) error {
+	if p.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if p.PageContent == "" {
+		return fmt.Errorf("'%s': missing page_content", p.ID)
+	}
+	if len(p.Vector) != size {
+		return fmt.Errorf("'%s': vector has %d dimensions, expected %d", p.ID, len(p.Vector), size)
+	}
+	return nil
+}
+
+// UploadMemory sends a JSON file of previously exported declarative memories (see MemoryFile), that are stored with their vectors
+func (s *RabbitHoleService) UploadMemory(ctx context.Context, r io.Reader) (*UploadResponse, error) {
+	resp, err := postMultipart(ctx, s.client, "/rabbithole/memory", http.Header{}, func(w *multipart.Writer) error {
+		part, err := createFormFile(w, "file", "memory.json")
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(part, r)
+		return err
+	}, &UploadResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value, nil
+}
+
+type allowedMimeTypesResponse struct {
+	Allowed []string `json:"allowed"`
+}
+
+// AllowedMimeTypes returns the MIME types of the files that the Cat is able to parse
+func (s *RabbitHoleService) AllowedMimeTypes(ctx context.Context) ([]string, error) {
+	resp, err := get(ctx, s.client, "/rabbithole/allowed-mimetypes", allowedMimeTypesResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Value.Allowed, nil
+}
+
+// FileUpload is a file to upload with UploadMany. The content is read from Open, if set, or from the file at Path.
+type FileUpload struct {
+	Name string
+	Path string
+	Open func() (io.ReadCloser, error)
+	Opts UploadOpts
+}
+
+type FileUploadResult struct {
+	File     FileUpload
+	Response *UploadResponse
+	Bytes    int64
+	Duration time.Duration
+	Err      error
+}
+
+// UploadMany uploads the files with a pool of workers, sending the result of each upload on the returned channel.
+// The channel is closed when all the files have been processed.
+func (s *RabbitHoleService) UploadMany(ctx context.Context, files []FileUpload, concurrency int) <-chan FileUploadResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	queue := make(chan FileUpload)
+	results := make(chan FileUploadResult, concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				results <- s.uploadOne(ctx, file)
+			}
+		}()
+	}
+
+	go func() {
+		for _, file := range files {
+			queue <- file
+		}
+		close(queue)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+func (s *RabbitHoleService) uploadOne(ctx context.Context, file FileUpload) FileUploadResult {
+	result := FileUploadResult{File: file}
+	start := time.Now()
+
+	open := file.Open
+	if open == nil {
+		open = func() (io.ReadCloser, error) { return os.Open(file.Path) }
+	}
+
+	rc, err := open()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer rc.Close()
+
+	name := file.Name
+	if name == "" {
+		name = filepath.Base(file.Path)
+	}
+
+	cr := &countingReader{r: rc}
+	result.Response, result.Err = s.UploadFile(ctx, name, cr, file.Opts)
+	result.Bytes = cr.n.Load()
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+// countingReader counts the bytes read. The count is atomic since the multipart body is read in another goroutine.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func writeUploadOpts(w *multipart.Writer, opts UploadOpts) error {
+	if opts.ChunkSize > 0 {
+		if err := w.WriteField("chunk_size", strconv.Itoa(opts.ChunkSize)); err != nil {
+			return err
+		}
+	}
+
+	if opts.ChunkOverlap > 0 {
+		if err := w.WriteField("chunk_overlap", strconv.Itoa(opts.ChunkOverlap)); err != nil {
+			return err
+		}
+	}
+
+	if len(opts.Metadata) > 0 {
+		metadata, err := json.Marshal(opts.Metadata)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteField("metadata", string(metadata)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createFormFile is like multipart.Writer.CreateFormFile, but it sets the content type from the file extension,
+// since the Cat uses it to choose the parser of the file
+func createFormFile(w *multipart.Writer, fieldname, filename string) (io.Writer, error) {
+	contentType := ContentType(filename)
+
+	escaper := strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escaper.Replace(fieldname), escaper.Replace(filename),
+	))
+	h.Set("Content-Type", contentType)
+
+	return w.CreatePart(h)
+}
+
+// extensionContentTypes are the types of the files parsed by the Cat, that could be missing from the system mime table
+var extensionContentTypes = map[string]string{
+	".md":   "text/markdown",
+	".html": "text/html",
+	".htm":  "text/html",
+	".txt":  "text/plain",
+	".pdf":  "application/pdf",
+	".zip":  "application/zip",
+	".json": "application/json",
+}
+
+// ContentType returns the MIME type of a file from its extension, as the Cat does to choose the parser of an uploaded file
+func ContentType(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if contentType, found := extensionContentTypes[ext]; found {
+		return contentType
+	}
+	// the system table could add parameters, like the charset, that the allowed types don't have
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(ext)); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
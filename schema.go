@@ -0,0 +1,564 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxRefDepth limits the resolution of recursive references: deeper schemas are left unresolved
+const maxRefDepth = 32
+
+// SchemaProperties is the JSON schema of a property. Properties are stored by value, as in the
+// first versions of the client.
+type SchemaProperties = Schema
+
+// Schema is a JSON schema, as generated by pydantic for the settings of LLMs, embedders and plugins
+type Schema struct {
+	Ref                  string                      `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Title                string                      `json:"title,omitempty" yaml:"title,omitempty"`
+	HumanReadableName    string                      `json:"humanReadableName,omitempty" yaml:"humanReadableName,omitempty"`
+	Description          string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	Link                 string                      `json:"link,omitempty" yaml:"link,omitempty"`
+	Type                 string                      `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                      `json:"format,omitempty" yaml:"format,omitempty"`
+	Default              any                         `json:"default,omitempty" yaml:"default,omitempty"`
+	Enum                 []any                       `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Const                any                         `json:"const,omitempty" yaml:"const,omitempty"`
+	AnyOf                []*Schema                   `json:"anyOf,omitempty" yaml:"anyOf,omitempty"`
+	OneOf                []*Schema                   `json:"oneOf,omitempty" yaml:"oneOf,omitempty"`
+	AllOf                []*Schema                   `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	Items                *Schema                     `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]SchemaProperties `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string                    `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties any                         `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Minimum              *float64                    `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                    `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	ExclusiveMinimum     *float64                    `json:"exclusiveMinimum,omitempty" yaml:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64                    `json:"exclusiveMaximum,omitempty" yaml:"exclusiveMaximum,omitempty"`
+	MinLength            *int                        `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int                        `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Pattern              string                      `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	MinItems             *int                        `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int                        `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Defs                 map[string]*Schema          `json:"$defs,omitempty" yaml:"$defs,omitempty"`
+	Definitions          map[string]*Schema          `json:"definitions,omitempty" yaml:"definitions,omitempty"`
+}
+
+// Resolve returns a copy of the schema with the references to its definitions replaced by the referenced schemas.
+// The annotations next to a reference (title, description, default) take precedence over the referenced ones.
+func (s *Schema) Resolve() (*Schema, error) {
+	return s.resolve(s, 0)
+}
+
+func (s *Schema) resolve(root *Schema, depth int) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+	if depth > maxRefDepth {
+		// a recursive schema is not expanded further, and its deepest values are accepted as they are
+		return &Schema{Title: s.Title, Description: s.Description, Default: s.Default}, nil
+	}
+
+	resolved := *s
+
+	if s.Ref != "" {
+		def, err := root.lookup(s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		target, err := def.resolve(root, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved = *target
+		if s.Title != "" {
+			resolved.Title = s.Title
+		}
+		if s.Description != "" {
+			resolved.Description = s.Description
+		}
+		if s.Default != nil {
+			resolved.Default = s.Default
+		}
+		return &resolved, nil
+	}
+
+	var err error
+	if resolved.Items, err = s.Items.resolve(root, depth+1); err != nil {
+		return nil, err
+	}
+	for _, list := range []*[]*Schema{&resolved.AnyOf, &resolved.OneOf, &resolved.AllOf} {
+		if *list == nil {
+			continue
+		}
+		schemas := make([]*Schema, len(*list))
+		for i, sub := range *list {
+			if schemas[i], err = sub.resolve(root, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		*list = schemas
+	}
+	if s.Properties != nil {
+		resolved.Properties = make(map[string]SchemaProperties, len(s.Properties))
+		for name, prop := range s.Properties {
+			resolvedProp, err := prop.resolve(root, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			resolved.Properties[name] = *resolvedProp
+		}
+	}
+
+	return &resolved, nil
+}
+
+// lookup finds the definition of a local reference, like '#/$defs/Name'
+func (s *Schema) lookup(ref string) (*Schema, error) {
+	var defs map[string]*Schema
+	var name string
+
+	switch {
+	case strings.HasPrefix(ref, "#/$defs/"):
+		defs, name = s.Defs, strings.TrimPrefix(ref, "#/$defs/")
+	case strings.HasPrefix(ref, "#/definitions/"):
+		defs, name = s.Definitions, strings.TrimPrefix(ref, "#/definitions/")
+	default:
+		return nil, fmt.Errorf("unsupported reference '%s'", ref)
+	}
+
+	def, found := defs[name]
+	if !found {
+		return nil, fmt.Errorf("missing definition of reference '%s'", ref)
+	}
+	return def, nil
+}
+
+// Validate checks the value against the schema, returning all the violations found
+func (s *Schema) Validate(value map[string]any) error {
+	resolved, err := s.Resolve()
+	if err != nil {
+		return err
+	}
+	return resolved.validate(value, "")
+}
+
+// ValidateStrict checks the value like Validate, also rejecting the keys that are not properties of the schema.
+// The Cat drops the unknown keys silently, so a misspelled one would be lost.
+func (s *Schema) ValidateStrict(value map[string]any) error {
+	resolved, err := s.Resolve()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	errs := []error{}
+	for _, key := range keys {
+		if _, found := resolved.Properties[key]; !found {
+			errs = append(errs, resolved.unknownProperty(key))
+		}
+	}
+	if err := resolved.validate(value, ""); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Schema) unknownProperty(key string) error {
+	available := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		available = append(available, name)
+	}
+	sort.Strings(available)
+	return fmt.Errorf("unknown property '%s' (available: %s)", key, strings.Join(available, ", "))
+}
+
+func (s *Schema) validate(value any, path string) error {
+	errs := []error{}
+	fail := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf("%s"+format, append([]any{pathPrefix(path)}, a...)...))
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		fail("expected %s, got %s", s.Type, typeOf(value))
+		return errors.Join(errs...)
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		fail("%s is not one of %s", formatValue(value), formatValues(s.Enum))
+	}
+	if s.Const != nil && !equalValues(s.Const, value) {
+		fail("%s is not %s", formatValue(value), formatValue(s.Const))
+	}
+
+	if n, ok := toFloat(value); ok {
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("%s is less than the minimum %s", formatValue(value), formatValue(*s.Minimum))
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("%s is greater than the maximum %s", formatValue(value), formatValue(*s.Maximum))
+		}
+		if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+			fail("%s must be greater than %s", formatValue(value), formatValue(*s.ExclusiveMinimum))
+		}
+		if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+			fail("%s must be less than %s", formatValue(value), formatValue(*s.ExclusiveMaximum))
+		}
+	}
+
+	if str, ok := value.(string); ok {
+		length := len([]rune(str))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must have at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must have at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err == nil && !re.MatchString(str) {
+				fail("'%s' does not match the pattern '%s'", str, s.Pattern)
+			}
+		}
+	}
+
+	if items, ok := value.([]any); ok {
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(items) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+
+	if obj, ok := value.(map[string]any); ok {
+		for _, name := range s.Required {
+			if _, found := obj[name]; !found {
+				fail("missing required property '%s'", name)
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			prop, found := s.Properties[name]
+			if !found {
+				if s.AdditionalProperties == false {
+					fail("unknown property '%s'", name)
+				}
+				continue
+			}
+			if err := prop.validate(obj[name], joinPath(path, name)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(s.AnyOf) > 0 && !matchesAny(s.AnyOf, value, path) {
+		fail("%s does not match any of the allowed types", formatValue(value))
+	}
+	if len(s.OneOf) > 0 && !matchesAny(s.OneOf, value, path) {
+		fail("%s does not match any of the allowed types", formatValue(value))
+	}
+	for _, sub := range s.AllOf {
+		if err := sub.validate(value, path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func matchesAny(schemas []*Schema, value any, path string) bool {
+	for _, sub := range schemas {
+		if sub.validate(value, path) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func pathPrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return "'" + path + "': "
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+func typeOf(value any) string {
+	for _, typ := range []string{"null", "string", "boolean", "integer", "number", "array", "object"} {
+		if hasType(value, typ) {
+			return typ
+		}
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equalValues(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if equalValues(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+func formatValues(values []any) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatValue(v)
+	}
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+// Coerce converts the string values, as typed on a command line, to the types of the schema properties.
+// Arrays and objects are parsed as JSON literals, and arrays can also be comma separated values.
+// Unknown properties and values not valid for their property are rejected.
+func (s *Schema) Coerce(values map[string]string) (map[string]any, error) {
+	resolved, err := s.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	coerced := map[string]any{}
+
+	for key, raw := range values {
+		prop, found := resolved.Properties[key]
+		if !found {
+			return nil, resolved.unknownProperty(key)
+		}
+
+		value, err := prop.coerce(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s': %w", key, err)
+		}
+		if err := prop.validate(value, key); err != nil {
+			return nil, err
+		}
+		coerced[key] = value
+	}
+
+	return coerced, nil
+}
+
+// coerce converts the string to the type of the schema, trying in order the alternatives of anyOf and oneOf
+func (s *Schema) coerce(raw string) (any, error) {
+	alternatives := append(append([]*Schema{}, s.AnyOf...), s.OneOf...)
+	if s.Type == "array" && s.Items != nil && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+		items := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			value, err := s.Items.coerce(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	}
+	if s.Type == "" {
+		// the untyped enums match the values by their text, so that numbers are kept as numbers
+		for _, value := range append(append([]any{}, s.Enum...), s.Const) {
+			if value != nil && formatValue(value) == raw {
+				return value, nil
+			}
+		}
+	}
+	if s.Type != "" || len(alternatives) == 0 {
+		return CoerceValue(s.Type, raw)
+	}
+
+	// null is preferred to the string "null" for optional properties
+	for _, alt := range alternatives {
+		if alt.Type == "null" && raw == "null" {
+			return nil, nil
+		}
+	}
+
+	var firstErr error
+	for _, alt := range alternatives {
+		if alt.Type == "null" {
+			continue
+		}
+		value, err := alt.coerce(raw)
+		if err == nil {
+			err = alt.validate(value, "")
+		}
+		if err == nil {
+			return value, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("'%s' does not match any of the allowed types", raw)
+	}
+	return nil, firstErr
+}
+
+// CoerceValue converts the string to the JSON schema type. Values of unknown types are kept as strings,
+// unless they are JSON objects or arrays.
+func CoerceValue(typ, raw string) (any, error) {
+	switch typ {
+	case "string":
+		var s string
+		if strings.HasPrefix(raw, `"`) && json.Unmarshal([]byte(raw), &s) == nil {
+			return s, nil
+		}
+		return raw, nil
+
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", raw)
+		}
+		return f, nil
+
+	case "integer":
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an integer", raw)
+		}
+		return i, nil
+
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a boolean", raw)
+		}
+		return b, nil
+
+	case "array":
+		if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			var a []any
+			if err := json.Unmarshal([]byte(raw), &a); err != nil {
+				return nil, fmt.Errorf("'%s' is not a JSON array: %w", raw, err)
+			}
+			return a, nil
+		}
+		a := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				a = append(a, item)
+			}
+		}
+		return a, nil
+
+	case "object":
+		var o map[string]any
+		if err := json.Unmarshal([]byte(raw), &o); err != nil {
+			return nil, fmt.Errorf("'%s' is not a JSON object: %w", raw, err)
+		}
+		return o, nil
+
+	case "null":
+		if raw != "null" && raw != "" {
+			return nil, fmt.Errorf("'%s' is not null", raw)
+		}
+		return nil, nil
+	}
+
+	if trimmed := strings.TrimSpace(raw); strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var v any
+		if err := json.Unmarshal([]byte(trimmed), &v); err == nil {
+			return v, nil
+		}
+	}
+	return raw, nil
+}
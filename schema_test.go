@@ -0,0 +1,351 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the schemas are shaped as the ones generated by pydantic for the settings of the Cat
+
+const optionalSchema = `{
+	"properties": {
+		"model": {"title": "Model", "type": "string"},
+		"temperature": {"anyOf": [{"type": "number"}, {"type": "null"}], "default": null, "title": "Temperature"}
+	},
+	"required": ["model"],
+	"title": "LLMOllamaConfig",
+	"type": "object"
+}`
+
+const enumRefSchema = `{
+	"$defs": {
+		"Task": {"enum": ["text-generation", "summarization"], "title": "Task", "type": "string"}
+	},
+	"properties": {
+		"task": {"$ref": "#/$defs/Task", "default": "text-generation"},
+		"fallback": {"allOf": [{"$ref": "#/$defs/Task"}], "description": "Task of the older pydantic versions"}
+	},
+	"required": ["task"],
+	"title": "LLMHuggingFaceEndpointConfig",
+	"type": "object"
+}`
+
+const numericSchema = `{
+	"properties": {
+		"max_tokens": {"default": 256, "title": "Max Tokens", "type": "integer"},
+		"top_p": {"default": 0.9, "maximum": 1, "minimum": 0, "title": "Top P", "type": "number"}
+	},
+	"title": "LLMGeminiChatConfig",
+	"type": "object"
+}`
+
+const recursiveSchema = `{
+	"$defs": {
+		"Node": {
+			"properties": {
+				"name": {"title": "Name", "type": "string"},
+				"child": {"anyOf": [{"$ref": "#/$defs/Node"}, {"type": "null"}], "default": null}
+			},
+			"required": ["name"],
+			"title": "Node",
+			"type": "object"
+		}
+	},
+	"properties": {
+		"tree": {"$ref": "#/$defs/Node"}
+	},
+	"title": "TreeConfig",
+	"type": "object"
+}`
+
+const closedSchema = `{
+	"additionalProperties": false,
+	"properties": {
+		"size": {"default": 128, "title": "Size", "type": "integer"}
+	},
+	"title": "EmbedderFakeConfig",
+	"type": "object"
+}`
+
+func parseSchema(t *testing.T, s string) *Schema {
+	t.Helper()
+
+	schema := &Schema{}
+	if err := json.Unmarshal([]byte(s), schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	return schema
+}
+
+// nestedTree returns a tree of Nodes of the given depth, with the name of the deepest one
+func nestedTree(depth int, leafName any) map[string]any {
+	node := map[string]any{"name": leafName}
+	for i := 1; i < depth; i++ {
+		node = map[string]any{"name": "node", "child": node}
+	}
+	return node
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  map[string]any
+		err    string
+	}{
+		{
+			name:   "optional set",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temperature": 0.2},
+		},
+		{
+			name:   "optional null",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temperature": nil},
+		},
+		{
+			name:   "optional missing",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral"},
+		},
+		{
+			name:   "optional wrong type",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temperature": "hot"},
+			err:    `'temperature': "hot" does not match any of the allowed types`,
+		},
+		{
+			name:   "missing required",
+			schema: optionalSchema,
+			value:  map[string]any{},
+			err:    "missing required property 'model'",
+		},
+		{
+			name:   "enum through $ref",
+			schema: enumRefSchema,
+			value:  map[string]any{"task": "summarization", "fallback": "text-generation"},
+		},
+		{
+			name:   "enum through $ref not allowed",
+			schema: enumRefSchema,
+			value:  map[string]any{"task": "translation"},
+			err:    "'task': \"translation\" is not one of",
+		},
+		{
+			name:   "enum through allOf not allowed",
+			schema: enumRefSchema,
+			value:  map[string]any{"task": "summarization", "fallback": "translation"},
+			err:    "'fallback': \"translation\" is not one of",
+		},
+		{
+			name:   "integer with a whole float",
+			schema: numericSchema,
+			value:  map[string]any{"max_tokens": float64(512), "top_p": 1},
+		},
+		{
+			name:   "integer with a fraction",
+			schema: numericSchema,
+			value:  map[string]any{"max_tokens": 1.5},
+			err:    "'max_tokens': expected integer, got number",
+		},
+		{
+			name:   "number out of range",
+			schema: numericSchema,
+			value:  map[string]any{"top_p": 1.5},
+			err:    "'top_p': 1.5 is greater than the maximum 1",
+		},
+		{
+			name:   "recursive",
+			schema: recursiveSchema,
+			value:  map[string]any{"tree": nestedTree(3, "leaf")},
+		},
+		{
+			name:   "recursive invalid",
+			schema: recursiveSchema,
+			value:  map[string]any{"tree": nestedTree(1, 42)},
+			err:    "'tree.name': expected string, got integer",
+		},
+		{
+			// below the depth cap the nodes are validated, and anyOf reports the whole child
+			name:   "recursive invalid child",
+			schema: recursiveSchema,
+			value:  map[string]any{"tree": nestedTree(2, 42)},
+			err:    `'tree.child': {"name":42} does not match any of the allowed types`,
+		},
+		{
+			// past the depth cap the nodes are not resolved anymore, and any value is accepted
+			name:   "recursive past the depth cap",
+			schema: recursiveSchema,
+			value:  map[string]any{"tree": nestedTree(maxRefDepth, 42)},
+		},
+		{
+			// pydantic ignores the extra keys by default
+			name:   "unknown key",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temprature": 0.2},
+		},
+		{
+			name:   "unknown key of a closed schema",
+			schema: closedSchema,
+			value:  map[string]any{"sise": 128},
+			err:    "unknown property 'sise'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseSchema(t, tt.schema).Validate(tt.value)
+			checkErr(t, err, tt.err)
+		})
+	}
+}
+
+func TestSchemaValidateStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		value  map[string]any
+		err    string
+	}{
+		{
+			name:   "known keys",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temperature": 0.2},
+		},
+		{
+			name:   "unknown key",
+			schema: optionalSchema,
+			value:  map[string]any{"model": "mistral", "temprature": 0.2},
+			err:    "unknown property 'temprature' (available: model, temperature)",
+		},
+		{
+			name:   "unknown key and invalid value",
+			schema: enumRefSchema,
+			value:  map[string]any{"tsk": "summarization"},
+			err:    "unknown property 'tsk' (available: fallback, task)\nmissing required property 'task'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseSchema(t, tt.schema).ValidateStrict(tt.value)
+			checkErr(t, err, tt.err)
+		})
+	}
+}
+
+func TestSchemaCoerce(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		values   map[string]string
+		expected map[string]any
+		err      string
+	}{
+		{
+			name:     "optional number",
+			schema:   optionalSchema,
+			values:   map[string]string{"temperature": "0.5"},
+			expected: map[string]any{"temperature": 0.5},
+		},
+		{
+			name:     "optional null",
+			schema:   optionalSchema,
+			values:   map[string]string{"temperature": "null"},
+			expected: map[string]any{"temperature": nil},
+		},
+		{
+			name:   "optional wrong type",
+			schema: optionalSchema,
+			values: map[string]string{"temperature": "hot"},
+			err:    "invalid value for 'temperature': 'hot' is not a number",
+		},
+		{
+			name:     "enum through $ref",
+			schema:   enumRefSchema,
+			values:   map[string]string{"task": "summarization"},
+			expected: map[string]any{"task": "summarization"},
+		},
+		{
+			name:   "enum through $ref not allowed",
+			schema: enumRefSchema,
+			values: map[string]string{"task": "translation"},
+			err:    "'task': \"translation\" is not one of",
+		},
+		{
+			name:     "integer and number",
+			schema:   numericSchema,
+			values:   map[string]string{"max_tokens": "512", "top_p": "1"},
+			expected: map[string]any{"max_tokens": int64(512), "top_p": float64(1)},
+		},
+		{
+			name:   "integer with a fraction",
+			schema: numericSchema,
+			values: map[string]string{"max_tokens": "1.5"},
+			err:    "invalid value for 'max_tokens': '1.5' is not an integer",
+		},
+		{
+			name:     "recursive",
+			schema:   recursiveSchema,
+			values:   map[string]string{"tree": `{"name": "root", "child": {"name": "leaf"}}`},
+			expected: map[string]any{"tree": map[string]any{"name": "root", "child": map[string]any{"name": "leaf"}}},
+		},
+		{
+			name:   "unknown key",
+			schema: optionalSchema,
+			values: map[string]string{"temprature": "0.2"},
+			err:    "unknown property 'temprature' (available: model, temperature)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coerced, err := parseSchema(t, tt.schema).Coerce(tt.values)
+			checkErr(t, err, tt.err)
+			if tt.err == "" && !reflect.DeepEqual(coerced, tt.expected) {
+				t.Errorf("coerced %#v, expected %#v", coerced, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSchemaResolveRecursive(t *testing.T) {
+	resolved, err := parseSchema(t, recursiveSchema).Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the references are expanded down to the depth cap, where an untyped schema is left
+	depth := 0
+	node := resolved.Properties["tree"]
+	for node.Type == "object" {
+		depth++
+		node = node.Properties["child"]
+		if len(node.AnyOf) == 2 {
+			node = *node.AnyOf[0]
+		}
+	}
+
+	if depth == 0 || depth > maxRefDepth {
+		t.Errorf("expanded %d levels, expected at most %d", depth, maxRefDepth)
+	}
+	if node.Ref != "" || len(node.Properties) > 0 || node.Type != "" {
+		t.Errorf("expected an untyped schema at the depth cap, got %+v", node)
+	}
+}
+
+func checkErr(t *testing.T, err error, expected string) {
+	t.Helper()
+
+	if expected == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error containing %q, got %v", expected, err)
+	}
+}